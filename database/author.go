@@ -2,7 +2,11 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"github.com/rs/zerolog/log"
 	"go_/structs"
+	"strings"
+	"time"
 )
 
 func CreateAuthor(author structs.Author) (int, error) {
@@ -42,19 +46,224 @@ func GetAuthorById(id int) (structs.Author, error) {
 	}
 	return author, nil
 }
+func GetAuthorByUID(uid string) (structs.Author, error) {
+	var author structs.Author
+	row := db.QueryRow("SELECT id,name,uid from author where uid=?", uid)
+	err := row.Scan(&author.ID, &author.Name, &author.UID)
+	return author, err
+}
+
+// GetOrCreateAuthor 以 Pixiv uid 作为作者身份，作者改名时更新 author.name 并记录到 author_name_history
 func GetOrCreateAuthor(author structs.Author) (structs.Author, error) {
-	existingAuthor, err := GetAuthorByName(author.Name)
-	if err == nil {
-		return existingAuthor, nil
-	} else if err == sql.ErrNoRows {
-		newAuthor := structs.Author{Name: author.Name, UID: author.UID}
-		id, err := CreateAuthor(newAuthor)
-		newAuthor.ID = id
+	if author.UID == "" {
+		return structs.Author{}, fmt.Errorf("author %s has no uid", author.Name)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return structs.Author{}, fmt.Errorf("failed to begin transaction for author uid %s: %w", author.UID, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO author (name, uid) VALUES (?, ?) ON CONFLICT(uid) DO NOTHING", author.Name, author.UID)
+	if err != nil {
+		return structs.Author{}, fmt.Errorf("failed to insert author uid %s: %w", author.UID, err)
+	}
+	var existingAuthor structs.Author
+	err = tx.QueryRow("SELECT id,name,uid from author where uid=?", author.UID).Scan(&existingAuthor.ID, &existingAuthor.Name, &existingAuthor.UID)
+	if err != nil {
+		return structs.Author{}, fmt.Errorf("failed to get author uid %s: %w", author.UID, err)
+	}
+	if author.Name != "" && existingAuthor.Name != author.Name {
+		log.Info().Str("uid", author.UID).Str("old_name", existingAuthor.Name).Str("new_name", author.Name).Msg("作者改名")
+		_, err = tx.Exec("UPDATE author SET name = ? WHERE id = ?", author.Name, existingAuthor.ID)
 		if err != nil {
-			return structs.Author{}, err
+			return structs.Author{}, fmt.Errorf("failed to rename author %d: %w", existingAuthor.ID, err)
 		}
-		return newAuthor, nil
-	} else {
+		existingAuthor.Name = author.Name
+	}
+	if err = recordAuthorName(tx, existingAuthor.ID, existingAuthor.Name); err != nil {
 		return structs.Author{}, err
 	}
+	return existingAuthor, tx.Commit()
+}
+
+func recordAuthorName(exec sqlExecutor, authorId int, name string) error {
+	if name == "" {
+		return nil
+	}
+	nowUnix := time.Now().Unix()
+	_, err := exec.Exec(`
+		INSERT INTO author_name_history (author_id, name, first_seen_at, last_seen_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(author_id, name) DO UPDATE SET last_seen_at = excluded.last_seen_at`,
+		authorId, name, nowUnix, nowUnix)
+	if err != nil {
+		return fmt.Errorf("failed to record name history for author %d: %w", authorId, err)
+	}
+	return nil
+}
+
+func GetAuthorNameHistory(authorId int) ([]structs.AuthorName, error) {
+	rows, err := db.Query(`
+		SELECT name, first_seen_at, last_seen_at
+		FROM author_name_history
+		WHERE author_id = ?
+		ORDER BY first_seen_at, id`, authorId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query name history for author %d: %w", authorId, err)
+	}
+	defer rows.Close()
+
+	var names []structs.AuthorName
+	for rows.Next() {
+		var name structs.AuthorName
+		if err := rows.Scan(&name.Name, &name.FirstSeenAt, &name.LastSeenAt); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// escapeLikePattern 转义 LIKE 的通配符，配合 ESCAPE '\' 使用
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// authorIdsByNameSubquery 需要绑定两次名字参数
+const authorIdsByNameSubquery = `SELECT id FROM author WHERE name = ? UNION SELECT author_id FROM author_name_history WHERE name = ?`
+
+var allowedAuthorSortColumns = map[string]string{
+	"id":             "a.id",
+	"name":           "a.name",
+	"image_count":    "image_count",
+	"bookmark_total": "bookmark_total",
+}
+
+// GetAuthors 分页列出作者，name 按子串匹配当前名字和历史名字
+func GetAuthors(name string, sortBy string, sortOrder string, page int, pageSize int) ([]structs.AuthorCount, int, error) {
+	var whereClause string
+	var args []interface{}
+	if name != "" {
+		whereClause = ` WHERE a.name LIKE ? ESCAPE '\' OR a.id IN (SELECT author_id FROM author_name_history WHERE name LIKE ? ESCAPE '\') `
+		pattern := "%" + escapeLikePattern(name) + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	var total int
+	err := db.QueryRow("SELECT COUNT(*) FROM author a"+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count authors: %w", err)
+	}
+
+	sortColumn, ok := allowedAuthorSortColumns[sortBy]
+	if !ok {
+		sortColumn = "image_count"
+	}
+	order := strings.ToUpper(sortOrder)
+	if !allowedSortOrders[order] {
+		order = "DESC"
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	query := `
+		SELECT a.id, a.name, a.uid, COUNT(i.id) AS image_count, COALESCE(SUM(i.bookmark_count), 0) AS bookmark_total
+		FROM author a
+		LEFT JOIN image i ON a.id = i.author_id` + whereClause + `
+		GROUP BY a.id, a.name, a.uid
+		ORDER BY ` + sortColumn + " " + order + `, a.id
+		LIMIT ? OFFSET ?`
+	rows, err := db.Query(query, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query authors: %w", err)
+	}
+	defer rows.Close()
+
+	authors := []structs.AuthorCount{}
+	for rows.Next() {
+		var author structs.AuthorCount
+		if err := rows.Scan(&author.ID, &author.Name, &author.UID, &author.Count, &author.BookmarkTotal); err != nil {
+			return nil, 0, err
+		}
+		authors = append(authors, author)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return authors, total, nil
+}
+
+func GetAuthorDetail(id int, tagLimit int) (structs.AuthorDetail, error) {
+	var detail structs.AuthorDetail
+	author, err := GetAuthorById(id)
+	if err != nil {
+		return detail, err
+	}
+	detail.Author = author
+
+	detail.Names, err = GetAuthorNameHistory(id)
+	if err != nil {
+		return detail, err
+	}
+	detail.Profile, err = GetAuthorProfile(id)
+	if err != nil {
+		return detail, fmt.Errorf("failed to get profile for author %d: %w", id, err)
+	}
+
+	err = db.QueryRow(`
+		SELECT COUNT(id), COALESCE(SUM(bookmark_count), 0), COALESCE(AVG(bookmark_count), 0),
+		       COALESCE(MAX(bookmark_count), 0), COALESCE(MIN(bookmark_count), 0),
+		       COALESCE(SUM(CASE WHEN is_bookmarked THEN 1 ELSE 0 END), 0)
+		FROM image
+		WHERE author_id = ?`, id).Scan(
+		&detail.ImageCount, &detail.BookmarkStats.Total, &detail.BookmarkStats.Average,
+		&detail.BookmarkStats.Max, &detail.BookmarkStats.Min, &detail.BookmarkStats.Bookmarked,
+	)
+	if err != nil {
+		return detail, fmt.Errorf("failed to query image stats for author %d: %w", id, err)
+	}
+
+	// 迁移时已有作者的名字历史记的是迁移时间，所以同时参考作品的上传时间和整数格式的更新时间
+	err = db.QueryRow(`
+		SELECT COALESCE(MIN(seen_at), 0), COALESCE(MAX(seen_at), 0)
+		FROM (
+			SELECT first_seen_at AS seen_at FROM author_name_history WHERE author_id = ?
+			UNION ALL
+			SELECT last_seen_at FROM author_name_history WHERE author_id = ?
+			UNION ALL
+			SELECT upload_date FROM image WHERE author_id = ? AND upload_date > 0
+			UNION ALL
+			SELECT updated_at FROM image WHERE author_id = ? AND typeof(updated_at) = 'integer'
+		)`, id, id, id, id).Scan(&detail.FirstSeenAt, &detail.LastSeenAt)
+	if err != nil {
+		return detail, fmt.Errorf("failed to query seen dates for author %d: %w", id, err)
+	}
+
+	rows, err := db.Query(`
+		SELECT t.id, t.name, COUNT(*) AS count
+		FROM image i
+		INNER JOIN image_tag it ON i.pid = it.image_id
+		INNER JOIN tag t ON it.tag_id = t.id
+		WHERE i.author_id = ?
+		GROUP BY t.id, t.name
+		ORDER BY count DESC, t.id
+		LIMIT ?`, id, tagLimit)
+	if err != nil {
+		return detail, fmt.Errorf("failed to query tag distribution for author %d: %w", id, err)
+	}
+	defer rows.Close()
+	detail.Tags = []structs.TagCount{}
+	for rows.Next() {
+		var tagCount structs.TagCount
+		if err := rows.Scan(&tagCount.ID, &tagCount.Name, &tagCount.Count); err != nil {
+			return detail, err
+		}
+		detail.Tags = append(detail.Tags, tagCount)
+	}
+	return detail, rows.Err()
 }
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// MarkAuthorFollowed 标记为已关注，followed_at 记录这次连续关注的开始时间
+func MarkAuthorFollowed(authorId int, private bool) error {
+	_, err := db.Exec(`
+		UPDATE author
+		SET followed_at = CASE WHEN COALESCE(is_followed, FALSE) AND followed_at IS NOT NULL THEN followed_at ELSE ? END,
+		    is_followed = TRUE, follow_private = ?, unfollowed_at = NULL
+		WHERE id = ?`, time.Now().Unix(), private, authorId)
+	if err != nil {
+		return fmt.Errorf("failed to mark author %d as followed: %w", authorId, err)
+	}
+	return nil
+}
+
+func MarkAuthorUnfollowed(authorId int) error {
+	_, err := db.Exec(`
+		UPDATE author
+		SET is_followed = FALSE, follow_private = FALSE, unfollowed_at = ?
+		WHERE id = ?`, time.Now().Unix(), authorId)
+	if err != nil {
+		return fmt.Errorf("failed to mark author %d as unfollowed: %w", authorId, err)
+	}
+	return nil
+}
+
+func GetFollowedAuthorIds() ([]int, error) {
+	rows, err := db.Query("SELECT id FROM author WHERE is_followed = TRUE ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query followed authors: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
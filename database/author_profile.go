@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	jsoniter "github.com/json-iterator/go"
+	"go_/structs"
+	"time"
+)
+
+// UpdateAuthorProfile 保存资料页上的信息，关注状态由关注列表同步（MarkAuthorFollowed / MarkAuthorUnfollowed）维护，这里不写
+func UpdateAuthorProfile(authorId int, profile structs.AuthorProfile) error {
+	social, err := jsoniter.MarshalToString(profile.Social)
+	if err != nil {
+		return fmt.Errorf("failed to marshal social links for author %d: %w", authorId, err)
+	}
+	_, err = db.Exec(`
+		UPDATE author
+		SET avatar_url = ?, avatar_big_url = ?, comment = ?, webpage = ?, social = ?,
+		    followed_back = ?, profile_updated_at = ?
+		WHERE id = ?`,
+		profile.AvatarURL, profile.AvatarBigURL, profile.Comment, profile.Webpage, social,
+		profile.FollowedBack, time.Now().Unix(), authorId)
+	if err != nil {
+		return fmt.Errorf("failed to update profile for author %d: %w", authorId, err)
+	}
+	return nil
+}
+
+func GetAuthorProfile(authorId int) (structs.AuthorProfile, error) {
+	var profile structs.AuthorProfile
+	var avatarURL, avatarBigURL, comment, webpage, social sql.NullString
+	var isFollowed, followedBack, followPrivate sql.NullBool
+	var updatedAt, followedAt sql.NullInt64
+	err := db.QueryRow(`
+		SELECT avatar_url, avatar_big_url, comment, webpage, social, is_followed, followed_back, profile_updated_at,
+		       follow_private, followed_at
+		FROM author
+		WHERE id = ?`, authorId).Scan(&avatarURL, &avatarBigURL, &comment, &webpage, &social, &isFollowed, &followedBack, &updatedAt,
+		&followPrivate, &followedAt)
+	if err != nil {
+		return profile, err
+	}
+	profile.AvatarURL = avatarURL.String
+	profile.AvatarBigURL = avatarBigURL.String
+	profile.Comment = comment.String
+	profile.Webpage = webpage.String
+	profile.IsFollowed = isFollowed.Bool
+	profile.FollowedBack = followedBack.Bool
+	profile.ProfileUpdatedAt = updatedAt.Int64
+	profile.FollowPrivate = followPrivate.Bool
+	profile.FollowedAt = followedAt.Int64
+	profile.Social = map[string]string{}
+	if social.String != "" {
+		if err := jsoniter.UnmarshalFromString(social.String, &profile.Social); err != nil {
+			return profile, fmt.Errorf("failed to parse social links for author %d: %w", authorId, err)
+		}
+	}
+	return profile, nil
+}
+
+// GetAuthorUIDsForProfileRefresh 返回从未获取过资料或资料早于 before 的作者 uid
+func GetAuthorUIDsForProfileRefresh(before int64) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT uid
+		FROM author
+		WHERE uid IS NOT NULL AND uid != ''
+		  AND (profile_updated_at IS NULL OR profile_updated_at < ?)
+		ORDER BY profile_updated_at IS NOT NULL, profile_updated_at, id`, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authors for profile refresh: %w", err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		uids = append(uids, uid)
+	}
+	return uids, rows.Err()
+}
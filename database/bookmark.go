@@ -0,0 +1,102 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MarkImageBookmarked 记录收藏状态并替换收藏标签，bookmarked_at 只在第一次发现收藏时写入
+func MarkImageBookmarked(pid int, bookmarkId string, private bool, tags []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for bookmark of pid %d: %w", pid, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE image
+		SET is_bookmarked = TRUE, bookmark_id = ?, bookmark_private = ?, bookmarked_at = COALESCE(bookmarked_at, ?)
+		WHERE pid = ?`, bookmarkId, private, time.Now().Unix(), pid)
+	if err != nil {
+		return fmt.Errorf("failed to mark pid %d as bookmarked: %w", pid, err)
+	}
+	_, err = tx.Exec("DELETE FROM image_bookmark_tag WHERE image_id = ?", pid)
+	if err != nil {
+		return fmt.Errorf("failed to clear bookmark tags of pid %d: %w", pid, err)
+	}
+	for _, tag := range tags {
+		_, err = tx.Exec("INSERT OR IGNORE INTO image_bookmark_tag (image_id, name) VALUES (?, ?)", pid, tag)
+		if err != nil {
+			return fmt.Errorf("failed to insert bookmark tag %s of pid %d: %w", tag, pid, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func UnmarkImageBookmarked(pid int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for unbookmarking pid %d: %w", pid, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE image
+		SET is_bookmarked = FALSE, bookmark_id = NULL, bookmark_private = FALSE, bookmarked_at = NULL
+		WHERE pid = ?`, pid)
+	if err != nil {
+		return fmt.Errorf("failed to unmark bookmark of pid %d: %w", pid, err)
+	}
+	_, err = tx.Exec("DELETE FROM image_bookmark_tag WHERE image_id = ?", pid)
+	if err != nil {
+		return fmt.Errorf("failed to clear bookmark tags of pid %d: %w", pid, err)
+	}
+	return tx.Commit()
+}
+
+func GetBookmarkedPids() ([]int, error) {
+	rows, err := db.Query("SELECT pid FROM image WHERE is_bookmarked = TRUE ORDER BY pid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookmarked pids: %w", err)
+	}
+	defer rows.Close()
+
+	var pids []int
+	for rows.Next() {
+		var pid int
+		if err := rows.Scan(&pid); err != nil {
+			return nil, err
+		}
+		pids = append(pids, pid)
+	}
+	return pids, rows.Err()
+}
+
+func GetBookmarkTagsByPid(pid int) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM image_bookmark_tag WHERE image_id = ? ORDER BY id", pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookmark tags of pid %d: %w", pid, err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetBookmarkId 返回记录的收藏 id，没有记录时返回空字符串
+func GetBookmarkId(pid int) (string, error) {
+	var bookmarkId sql.NullString
+	err := db.QueryRow("SELECT bookmark_id FROM image WHERE pid = ?", pid).Scan(&bookmarkId)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to query bookmark id of pid %d: %w", pid, err)
+	}
+	return bookmarkId.String, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+	"go_/structs"
+)
+
+func InsertBookmarkSnapshot(pid int, bookmarkCount int, capturedAt int64) error {
+	_, err := db.Exec("INSERT INTO bookmark_snapshot (pid, bookmark_count, captured_at) VALUES (?, ?, ?)", pid, bookmarkCount, capturedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert bookmark snapshot for pid %d: %w", pid, err)
+	}
+	return nil
+}
+
+// GetBookmarkHistory 返回作品收藏数的时间序列，since 大于 0 时只返回之后的快照
+func GetBookmarkHistory(pid int, since int64) ([]structs.BookmarkSnapshot, error) {
+	rows, err := db.Query(`
+		SELECT bookmark_count, captured_at
+		FROM bookmark_snapshot
+		WHERE pid = ? AND captured_at >= ?
+		ORDER BY captured_at, id`, pid, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookmark history for pid %d: %w", pid, err)
+	}
+	defer rows.Close()
+
+	snapshots := []structs.BookmarkSnapshot{}
+	for rows.Next() {
+		var snapshot structs.BookmarkSnapshot
+		if err := rows.Scan(&snapshot.BookmarkCount, &snapshot.CapturedAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// GetTrendingImages 按 since 之后的收藏增长排序。起点取 since 之前最后一条快照，
+// 没有时取窗口内第一条，所以窗口内才入库的作品从入库时开始计算
+func GetTrendingImages(since int64, limit int) ([]structs.TrendingImage, error) {
+	rows, err := db.Query(`
+		SELECT pid, baseline, latest, latest - baseline AS growth
+		FROM (
+			SELECT s.pid,
+			       COALESCE(
+			           (SELECT b.bookmark_count FROM bookmark_snapshot b WHERE b.pid = s.pid AND b.captured_at <= ? ORDER BY b.captured_at DESC, b.id DESC LIMIT 1),
+			           (SELECT b.bookmark_count FROM bookmark_snapshot b WHERE b.pid = s.pid ORDER BY b.captured_at, b.id LIMIT 1)
+			       ) AS baseline,
+			       (SELECT b.bookmark_count FROM bookmark_snapshot b WHERE b.pid = s.pid ORDER BY b.captured_at DESC, b.id DESC LIMIT 1) AS latest
+			FROM (SELECT DISTINCT pid FROM bookmark_snapshot WHERE captured_at > ?) s
+		)
+		WHERE latest > baseline
+		ORDER BY growth DESC, pid DESC
+		LIMIT ?`, since, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trending images: %w", err)
+	}
+	trending := []structs.TrendingImage{}
+	for rows.Next() {
+		var item structs.TrendingImage
+		if err := rows.Scan(&item.Image.PID, &item.Baseline, &item.Latest, &item.Growth); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		trending = append(trending, item)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range trending {
+		trending[i].Image, err = GetImageById(trending[i].Image.PID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get trending image %d: %w", trending[i].Image.PID, err)
+		}
+	}
+	return trending, nil
+}
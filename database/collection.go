@@ -0,0 +1,320 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_/structs"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCollectionNotFound     = errors.New("collection not found")
+	ErrCollectionNameConflict = errors.New("collection name is already used")
+	ErrCollectionImageMissing = errors.New("image is not in the collection")
+)
+
+// collectionColumns 与 scanCollection 对应，封面优先取 cover_pid，否则取 position 最小的作品
+const collectionColumns = `
+	c.id, c.name, c.description, COALESCE(c.created_at, 0), COALESCE(c.updated_at, 0),
+	(SELECT COUNT(*) FROM collection_image ci WHERE ci.collection_id = c.id),
+	COALESCE(c.cover_pid, (SELECT ci.image_id FROM collection_image ci WHERE ci.collection_id = c.id ORDER BY ci.position, ci.id LIMIT 1), 0)`
+
+func scanCollection(row rowScanner) (structs.Collection, error) {
+	var collection structs.Collection
+	err := row.Scan(&collection.ID, &collection.Name, &collection.Description, &collection.CreatedAt,
+		&collection.UpdatedAt, &collection.ImageCount, &collection.CoverPID)
+	return collection, err
+}
+
+func fillCollectionCover(collection *structs.Collection) error {
+	if collection.CoverPID == 0 {
+		return nil
+	}
+	err := db.QueryRow("SELECT COALESCE(url_original, ''), COALESCE(url_mini, ''), COALESCE(url_thumb, ''), COALESCE(url_small, ''), COALESCE(url_regular, '') FROM image WHERE pid = ?",
+		collection.CoverPID).Scan(&collection.CoverURLs.Original, &collection.CoverURLs.Mini, &collection.CoverURLs.Thumb,
+		&collection.CoverURLs.Small, &collection.CoverURLs.Regular)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get cover of collection %d: %w", collection.ID, err)
+	}
+	return nil
+}
+
+func GetCollections() ([]structs.Collection, error) {
+	rows, err := db.Query("SELECT " + collectionColumns + " FROM collection c ORDER BY c.name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %w", err)
+	}
+	collections := []structs.Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range collections {
+		if err := fillCollectionCover(&collections[i]); err != nil {
+			return nil, err
+		}
+	}
+	return collections, nil
+}
+
+func GetCollection(id int) (structs.Collection, error) {
+	collection, err := scanCollection(db.QueryRow("SELECT "+collectionColumns+" FROM collection c WHERE c.id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return collection, ErrCollectionNotFound
+	}
+	if err != nil {
+		return collection, fmt.Errorf("failed to get collection %d: %w", id, err)
+	}
+	return collection, fillCollectionCover(&collection)
+}
+
+func isUniqueConstraintError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func CreateCollection(name string, description string) (int, error) {
+	now := time.Now().Unix()
+	result, err := db.Exec("INSERT INTO collection (name, description, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		name, description, now, now)
+	if isUniqueConstraintError(err) {
+		return 0, ErrCollectionNameConflict
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create collection %q: %w", name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get id of collection %q: %w", name, err)
+	}
+	return int(id), nil
+}
+
+// UpdateCollection 修改 payload 中不为 nil 的字段，封面必须是集合里的作品
+func UpdateCollection(id int, payload structs.CollectionPayload) error {
+	var sets []string
+	var args []interface{}
+	if payload.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, *payload.Name)
+	}
+	if payload.Description != nil {
+		sets = append(sets, "description = ?")
+		args = append(args, *payload.Description)
+	}
+	if payload.CoverPID != nil {
+		if *payload.CoverPID == 0 {
+			sets = append(sets, "cover_pid = NULL")
+		} else {
+			var inCollection bool
+			err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM collection_image WHERE collection_id = ? AND image_id = ?)", id, *payload.CoverPID).Scan(&inCollection)
+			if err != nil {
+				return fmt.Errorf("failed to check cover of collection %d: %w", id, err)
+			}
+			if !inCollection {
+				return ErrCollectionImageMissing
+			}
+			sets = append(sets, "cover_pid = ?")
+			args = append(args, *payload.CoverPID)
+		}
+	}
+	sets = append(sets, "updated_at = ?")
+	args = append(args, time.Now().Unix(), id)
+
+	result, err := db.Exec("UPDATE collection SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...)
+	if isUniqueConstraintError(err) {
+		return ErrCollectionNameConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update collection %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+func DeleteCollection(id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for deleting collection %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM collection WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCollectionNotFound
+	}
+	if _, err = tx.Exec("DELETE FROM collection_image WHERE collection_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete images of collection %d: %w", id, err)
+	}
+	return tx.Commit()
+}
+
+func collectionExists(tx *sql.Tx, id int) error {
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM collection WHERE id = ?)", id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check collection %d: %w", id, err)
+	}
+	if !exists {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// AddImagesToCollection 按顺序把作品追加到集合末尾，已在集合里或不在库里的作品跳过，返回新增数量
+func AddImagesToCollection(id int, pids []int) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction for collection %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	if err = collectionExists(tx, id); err != nil {
+		return 0, err
+	}
+	var position int
+	err = tx.QueryRow("SELECT COALESCE(MAX(position), -1) + 1 FROM collection_image WHERE collection_id = ?", id).Scan(&position)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next position of collection %d: %w", id, err)
+	}
+
+	now := time.Now().Unix()
+	added := 0
+	for _, pid := range pids {
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO collection_image (collection_id, image_id, position, added_at)
+			SELECT ?, pid, ?, ? FROM image WHERE pid = ?`, id, position, now, pid)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add pid %d to collection %d: %w", pid, id, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if affected > 0 {
+			added++
+			position++
+		}
+	}
+	if _, err = tx.Exec("UPDATE collection SET updated_at = ? WHERE id = ?", now, id); err != nil {
+		return 0, fmt.Errorf("failed to touch collection %d: %w", id, err)
+	}
+	return added, tx.Commit()
+}
+
+// RemoveImagesFromCollection 移除作品，被移除的作品是手动封面时清除封面，返回移除数量
+func RemoveImagesFromCollection(id int, pids []int) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction for collection %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	if err = collectionExists(tx, id); err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, pid := range pids {
+		result, err := tx.Exec("DELETE FROM collection_image WHERE collection_id = ? AND image_id = ?", id, pid)
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove pid %d from collection %d: %w", pid, id, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		removed += int(affected)
+	}
+	_, err = tx.Exec(`
+		UPDATE collection SET updated_at = ?,
+		    cover_pid = CASE WHEN cover_pid IN (SELECT image_id FROM collection_image WHERE collection_id = ?) THEN cover_pid END
+		WHERE id = ?`, time.Now().Unix(), id, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update collection %d after removal: %w", id, err)
+	}
+	return removed, tx.Commit()
+}
+
+// ReorderCollection 把 pids 按给定顺序排到集合最前面，其余作品保持原来的相对顺序排在后面
+func ReorderCollection(id int, pids []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for collection %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	if err = collectionExists(tx, id); err != nil {
+		return err
+	}
+	rows, err := tx.Query("SELECT image_id FROM collection_image WHERE collection_id = ? ORDER BY position, id", id)
+	if err != nil {
+		return fmt.Errorf("failed to query images of collection %d: %w", id, err)
+	}
+	var current []int
+	members := make(map[int]bool)
+	for rows.Next() {
+		var pid int
+		if err := rows.Scan(&pid); err != nil {
+			rows.Close()
+			return err
+		}
+		current = append(current, pid)
+		members[pid] = true
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	ordered := make([]int, 0, len(current))
+	placed := make(map[int]bool)
+	for _, pid := range pids {
+		if !members[pid] {
+			return fmt.Errorf("%w: %d", ErrCollectionImageMissing, pid)
+		}
+		if !placed[pid] {
+			ordered = append(ordered, pid)
+			placed[pid] = true
+		}
+	}
+	for _, pid := range current {
+		if !placed[pid] {
+			ordered = append(ordered, pid)
+		}
+	}
+
+	for position, pid := range ordered {
+		_, err := tx.Exec("UPDATE collection_image SET position = ? WHERE collection_id = ? AND image_id = ?", position, id, pid)
+		if err != nil {
+			return fmt.Errorf("failed to move pid %d in collection %d: %w", pid, id, err)
+		}
+	}
+	if _, err = tx.Exec("UPDATE collection SET updated_at = ? WHERE id = ?", time.Now().Unix(), id); err != nil {
+		return fmt.Errorf("failed to touch collection %d: %w", id, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetConfigValue 读取 configuration 表中的值，不存在时返回空字符串
+func GetConfigValue(key string) (string, error) {
+	var value sql.NullString
+	err := db.QueryRow("SELECT value FROM configuration WHERE key = ?", key).Scan(&value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read configuration %s: %w", key, err)
+	}
+	return value.String, nil
+}
+
+func SetConfigValue(key string, value string) error {
+	_, err := db.Exec(`
+		INSERT INTO configuration (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to write configuration %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetConfigValuesByPrefix 返回 key 以 prefix 开头的所有配置，结果的 key 去掉了 prefix
+func GetConfigValuesByPrefix(prefix string) (map[string]string, error) {
+	rows, err := db.Query("SELECT key, value FROM configuration WHERE substr(key, 1, ?) = ?", len(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration with prefix %s: %w", prefix, err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		values[key[len(prefix):]] = value.String
+	}
+	return values, rows.Err()
+}
@@ -10,12 +10,15 @@ import (
 	"time"
 )
 
-func CreateImage(pid int, name string, authorId int, bookmarkCount int, isBookmarked bool, urls structs.ImageURLs) (int, error) {
+func CreateImage(pid int, name string, authorId int, bookmarkCount int, isBookmarked bool, urls structs.ImageURLs, meta structs.IllustMeta) (int, error) {
 	nowUnix := time.Now().Unix()
 	result, err := db.Exec(`
-        INSERT INTO image(pid, author_id, name, url_original,url_mini, url_thumb, url_small, url_regular,updated_at,bookmark_count,is_bookmarked)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?,?,?,?)`,
-		pid, authorId, name, urls.Original, urls.Mini, urls.Thumb, urls.Small, urls.Regular, nowUnix, bookmarkCount, isBookmarked)
+        INSERT INTO image(pid, author_id, name, url_original,url_mini, url_thumb, url_small, url_regular,updated_at,bookmark_count,is_bookmarked,
+                          description, create_date, upload_date, illust_type, x_restrict, ai_type, width, height,
+                          page_count, view_count, like_count, comment_count, series_id, series_title)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?,?,?,?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		append([]interface{}{pid, authorId, name, urls.Original, urls.Mini, urls.Thumb, urls.Small, urls.Regular, nowUnix, bookmarkCount, isBookmarked},
+			illustMetaArgs(meta)...)...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to execute insert for pid %d: %w", pid, err)
 	}
@@ -23,17 +26,25 @@ func CreateImage(pid int, name string, authorId int, bookmarkCount int, isBookma
 	if err != nil {
 		return 0, fmt.Errorf("failed to get last insert id for pid %d: %w", pid, err)
 	}
+	if err = InsertBookmarkSnapshot(pid, bookmarkCount, nowUnix); err != nil {
+		return 0, err
+	}
 	return int(id), nil
 }
 
-func UpdateImage(pid int, name string, authorId int, bookmarkCount int, isBookmarked bool, urls structs.ImageURLs) error {
+func UpdateImage(pid int, name string, authorId int, bookmarkCount int, isBookmarked bool, urls structs.ImageURLs, meta structs.IllustMeta) error {
 	nowUnix := time.Now().Unix()
+	args := []interface{}{authorId, name, urls.Original, urls.Mini, urls.Thumb, urls.Small, urls.Regular, nowUnix, bookmarkCount, isBookmarked}
+	args = append(args, illustMetaArgs(meta)...)
+	args = append(args, pid)
 	result, err := db.Exec(`
         UPDATE image
         SET author_id = ?, name = ?, url_original = ?, url_mini = ?,
-            url_thumb = ?, url_small = ?, url_regular = ?, updated_at = ?, bookmark_count = ?,is_bookmarked=?
-        WHERE pid = ?`,
-		authorId, name, urls.Original, urls.Mini, urls.Thumb, urls.Small, urls.Regular, nowUnix, bookmarkCount, isBookmarked, pid)
+            url_thumb = ?, url_small = ?, url_regular = ?, updated_at = ?, bookmark_count = ?,is_bookmarked=?,
+            description = ?, create_date = ?, upload_date = ?, illust_type = ?, x_restrict = ?, ai_type = ?,
+            width = ?, height = ?, page_count = ?, view_count = ?, like_count = ?, comment_count = ?,
+            series_id = ?, series_title = ?
+        WHERE pid = ?`, args...)
 	if err != nil {
 		return fmt.Errorf("failed to execute update for pid %d: %w", pid, err)
 	}
@@ -47,73 +58,125 @@ func UpdateImage(pid int, name string, authorId int, bookmarkCount int, isBookma
 		return fmt.Errorf("update failed for pid %d: record not found (or data was identical)", pid)
 	}
 
-	return nil
+	return InsertBookmarkSnapshot(pid, bookmarkCount, nowUnix)
 }
 
-func GetImageById(pid int) (structs.Image, error) {
-	var image structs.Image
-	var err error
+// illustMetaArgs 按 description ... series_title 的列顺序展开元数据，没有系列时 series_id 存 NULL
+func illustMetaArgs(meta structs.IllustMeta) []interface{} {
+	var seriesId interface{}
+	if meta.SeriesID > 0 {
+		seriesId = meta.SeriesID
+	}
+	return []interface{}{
+		meta.Description, meta.CreateDate, meta.UploadDate, meta.IllustType, meta.XRestrict, meta.AIType,
+		meta.Width, meta.Height, meta.PageCount, meta.ViewCount, meta.LikeCount, meta.CommentCount,
+		seriesId, meta.SeriesTitle,
+	}
+}
 
-	row := db.QueryRow(`
-       SELECT id, pid, author_id, name, bookmark_count, is_bookmarked, local,
-              url_original, url_mini, url_thumb, url_small, url_regular
-       FROM image
-       WHERE pid = ?
-    `, pid)
+// imageColumns 是读取 structs.Image 时的列，顺序与 scanImage 一致，image 表的别名为 i
+const imageColumns = `i.id, i.pid, i.author_id, i.name, i.bookmark_count, i.is_bookmarked, i.local,
+       i.url_original, i.url_mini, i.url_thumb, i.url_small, i.url_regular,
+       COALESCE(i.description, ''), COALESCE(i.create_date, 0), COALESCE(i.upload_date, 0),
+       COALESCE(i.illust_type, 0), COALESCE(i.x_restrict, 0), COALESCE(i.ai_type, 0),
+       COALESCE(i.width, 0), COALESCE(i.height, 0), COALESCE(i.page_count, 0),
+       COALESCE(i.view_count, 0), COALESCE(i.like_count, 0), COALESCE(i.comment_count, 0),
+       COALESCE(i.series_id, 0), COALESCE(i.series_title, '')`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	err = row.Scan(
+func scanImage(row rowScanner) (structs.Image, error) {
+	var image structs.Image
+	err := row.Scan(
 		&image.ID, &image.PID, &image.Author.ID, &image.Name,
 		&image.BookmarkCount, &image.IsBookmarked, &image.Local,
 		&image.URLs.Original, &image.URLs.Mini, &image.URLs.Thumb, &image.URLs.Small, &image.URLs.Regular,
+		&image.Description, &image.CreateDate, &image.UploadDate,
+		&image.IllustType, &image.XRestrict, &image.AIType,
+		&image.Width, &image.Height, &image.PageCount,
+		&image.ViewCount, &image.LikeCount, &image.CommentCount,
+		&image.SeriesID, &image.SeriesTitle,
 	)
+	return image, err
+}
+
+func GetImageById(pid int) (structs.Image, error) {
+	row := db.QueryRow("SELECT "+imageColumns+" FROM image i WHERE i.pid = ?", pid)
+
+	image, err := scanImage(row)
 	if err != nil {
 		return image, err
 	}
+	err = fillImageDetails(&image)
+	return image, err
+}
 
+// fillImageDetails 补上作者、tag、分页和收藏 tag
+func fillImageDetails(image *structs.Image) error {
+	var err error
 	image.Author, err = GetAuthorById(image.Author.ID)
 	if err != nil {
-		return image, fmt.Errorf("failed to get author %d for image %d: %w", image.Author.ID, pid, err)
+		return fmt.Errorf("failed to get author %d for image %d: %w", image.Author.ID, image.PID, err)
 	}
-	image.Tags, err = GetTagsByPid(pid)
+	image.Tags, err = GetTagsByPid(image.PID)
 	if err != nil {
-		return image, fmt.Errorf("failed to get tags for image %d: %w", pid, err)
+		return fmt.Errorf("failed to get tags for image %d: %w", image.PID, err)
 	}
-	image.Pages, err = GetPageByPid(pid)
+	image.Pages, err = GetPageByPid(image.PID)
 	if err != nil {
-		return image, fmt.Errorf("failed to get pages for image %d: %w", pid, err)
+		return fmt.Errorf("failed to get pages for image %d: %w", image.PID, err)
 	}
-	return image, nil
+	image.BookmarkTags, err = GetBookmarkTagsByPid(image.PID)
+	return err
 }
 
-func GetAuthorImageCounts() ([]structs.AuthorCount, error) {
-	var authorImageCounts []structs.AuthorCount
+// GetAuthorImageCounts 统计搜索条件匹配的作品里每个作者的作品数和收藏总数，返回当前页和满足 min_count 的作者总数
+func GetAuthorImageCounts(req structs.StatisticsRequest) ([]structs.AuthorCount, int, error) {
+	pidQuery, args := buildPidQuery(req.Search)
+	grouped := `
+		SELECT a.id, a.name, a.uid, COUNT(i.id) AS image_count, COALESCE(SUM(i.bookmark_count), 0) AS bookmark_total
+		FROM author a
+		INNER JOIN image i ON a.id = i.author_id
+		WHERE i.pid IN (` + pidQuery + `)
+		GROUP BY a.id, a.name, a.uid
+		HAVING COUNT(i.id) >= ?`
+	args = append(args, req.MinCount)
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM ("+grouped+")", args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count author statistics: %w", err)
+	}
+
+	sortColumn, ok := allowedAuthorSortColumns[req.SortBy]
+	if !ok {
+		sortColumn = "image_count"
+	}
+	query := grouped + " ORDER BY " + sortColumn + " " + statisticsSortOrder(req.SortOrder) + ", a.id"
+	query, args = appendStatisticsPaging(query, args, req)
 
-	rows, err := db.Query(`
-        SELECT a.id, a.name, COUNT(i.id) AS image_count
-        FROM author a
-        INNER JOIN image i ON a.id = i.author_id
-        GROUP BY a.id, a.name
-        ORDER BY image_count DESC
-    `)
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("failed to query author statistics: %w", err)
 	}
 	defer rows.Close()
 
+	authorImageCounts := []structs.AuthorCount{}
 	for rows.Next() {
 		var authorImageCount structs.AuthorCount
-		err := rows.Scan(&authorImageCount.ID, &authorImageCount.Name, &authorImageCount.Count)
+		err := rows.Scan(&authorImageCount.ID, &authorImageCount.Name, &authorImageCount.UID, &authorImageCount.Count, &authorImageCount.BookmarkTotal)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		authorImageCounts = append(authorImageCounts, authorImageCount)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return authorImageCounts, nil
+	return authorImageCounts, total, nil
 }
 
 var allowedSortColumns = map[string]bool{
@@ -121,6 +184,14 @@ var allowedSortColumns = map[string]bool{
 	"pid":            true,
 	"name":           true,
 	"bookmark_count": true,
+	"create_date":    true,
+	"upload_date":    true,
+	"view_count":     true,
+	"like_count":     true,
+	"comment_count":  true,
+	"page_count":     true,
+	"width":          true,
+	"height":         true,
 }
 
 var allowedSortOrders = map[string]bool{
@@ -128,18 +199,18 @@ var allowedSortOrders = map[string]bool{
 	"DESC": true,
 }
 
-func SearchImages(tags []string, pageNum int, pageSize int, authorName string, sortBy string, sortOrder string, minBookmarkCount *int, maxBookmarkCount *int, isBookmarked *bool) ([]structs.Image, int, error) {
+func SearchImages(req structs.SearchRequest) ([]structs.Image, int, error) {
 	var images []structs.Image
 	var count int
 
-	query, args := buildQuery(tags, pageNum, pageSize, authorName, sortBy, sortOrder, minBookmarkCount, maxBookmarkCount, isBookmarked)
+	query, args := buildQuery(req)
 	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	countQuery, countArgs := buildCountQuery(tags, authorName, minBookmarkCount, maxBookmarkCount, isBookmarked)
+	countQuery, countArgs := buildCountQuery(req)
 	log.Debug().Str("query", countQuery).Interface("args", countArgs).Msg("Executing SearchImages count query") // Debug 日志
 
 	err = db.QueryRow(countQuery, countArgs...).Scan(&count)
@@ -151,27 +222,13 @@ func SearchImages(tags []string, pageNum int, pageSize int, authorName string, s
 	}
 
 	for rows.Next() {
-		var image structs.Image
-		err = rows.Scan(
-			&image.ID, &image.PID, &image.Author.ID, &image.Name,
-			&image.BookmarkCount, &image.IsBookmarked, &image.Local,
-			&image.URLs.Original, &image.URLs.Mini, &image.URLs.Thumb, &image.URLs.Small, &image.URLs.Regular,
-		)
+		image, err := scanImage(rows)
 		if err != nil {
 			return nil, 0, err
 		}
 
-		image.Author, err = GetAuthorById(image.Author.ID)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get author %d during search: %w", image.Author.ID, err)
-		}
-		image.Tags, err = GetTagsByPid(image.PID)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get tags for pid %d during search: %w", image.PID, err)
-		}
-		image.Pages, err = GetPageByPid(image.PID)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get pages for pid %d during search: %w", image.PID, err)
+		if err = fillImageDetails(&image); err != nil {
+			return nil, 0, err
 		}
 		images = append(images, image)
 	}
@@ -195,55 +252,14 @@ func CheckPidExists(pid int) (bool, error) {
 	}
 	return exists, nil
 }
-func buildQuery(tags []string, page int, pageSize int, authorName string, sortBy string, sortOrder string, minBookmarkCount *int, maxBookmarkCount *int, isBookmarked *bool) (string, []interface{}) {
+func buildQuery(req structs.SearchRequest) (string, []interface{}) {
 	var sb strings.Builder
-	var args []interface{}
-	var whereConditions []string
-	var joinClauses []string
 
-	sb.WriteString(`SELECT DISTINCT i.id, i.pid, i.author_id, i.name, i.bookmark_count, i.is_bookmarked, i.local,
-                       i.url_original, i.url_mini, i.url_thumb, i.url_small, i.url_regular `)
+	sb.WriteString("SELECT DISTINCT " + imageColumns + " ")
 
 	sb.WriteString(" FROM image i ")
 
-	hasTags := len(tags) > 0
-
-	if authorName != "" {
-		joinClauses = append(joinClauses, " JOIN author a ON i.author_id = a.id ")
-	}
-
-	if hasTags {
-		joinClauses = append(joinClauses, " JOIN image_tag it ON i.pid = it.image_id ")
-		joinClauses = append(joinClauses, " JOIN tag t ON it.tag_id = t.id ")
-	}
-
-	whereConditions = append(whereConditions, "i.url_regular IS NOT NULL")
-
-	if authorName != "" {
-		whereConditions = append(whereConditions, "a.name = ?")
-		args = append(args, authorName)
-	}
-
-	if minBookmarkCount != nil {
-		whereConditions = append(whereConditions, "i.bookmark_count >= ?")
-		args = append(args, *minBookmarkCount)
-	}
-	if maxBookmarkCount != nil {
-		whereConditions = append(whereConditions, "i.bookmark_count <= ?")
-		args = append(args, *maxBookmarkCount)
-	}
-
-	if isBookmarked != nil {
-		whereConditions = append(whereConditions, "i.is_bookmarked = ?")
-		args = append(args, *isBookmarked)
-	}
-
-	if hasTags {
-		whereConditions = append(whereConditions, "t.name IN ("+strings.Repeat("?,", len(tags)-1)+"?)")
-		for _, tag := range tags {
-			args = append(args, tag)
-		}
-	}
+	joinClauses, whereConditions, args := buildSearchConditions(req)
 
 	if len(joinClauses) > 0 {
 		sb.WriteString(strings.Join(joinClauses, ""))
@@ -254,27 +270,23 @@ func buildQuery(tags []string, page int, pageSize int, authorName string, sortBy
 		sb.WriteString(strings.Join(whereConditions, " AND "))
 	}
 
-	if hasTags {
-		sb.WriteString(` GROUP BY i.id, i.pid, i.author_id, i.name, i.bookmark_count, i.is_bookmarked, i.local,i.url_original, i.url_mini, i.url_thumb, i.url_small, i.url_regular `)
-		sb.WriteString(" HAVING COUNT(DISTINCT t.id) = ? ")
-		args = append(args, len(tags))
-	}
-
 	dbSortColumn := "i.pid"
-	safeSortBy, sortByOK := allowedSortColumns[sortBy]
+	safeSortBy, sortByOK := allowedSortColumns[req.SortBy]
 	if sortByOK && safeSortBy {
-		if sortBy == "name" || sortBy == "id" || sortBy == "pid" || sortBy == "bookmark_count" {
-			dbSortColumn = "i." + sortBy
-		}
+		dbSortColumn = "i." + req.SortBy
+	} else if req.SortBy == "position" && req.CollectionID != nil {
+		dbSortColumn = "ci.position"
 	}
 
 	dbSortOrder := "DESC"
-	safeSortOrder, orderOK := allowedSortOrders[strings.ToUpper(sortOrder)]
+	safeSortOrder, orderOK := allowedSortOrders[strings.ToUpper(req.SortOrder)]
 	if orderOK && safeSortOrder {
-		dbSortOrder = strings.ToUpper(sortOrder)
+		dbSortOrder = strings.ToUpper(req.SortOrder)
 	}
 	sb.WriteString(fmt.Sprintf(" ORDER BY %s %s ", dbSortColumn, dbSortOrder))
 
+	page := req.Page
+	pageSize := req.PageSize
 	if page < 1 {
 		page = 1
 	}
@@ -288,124 +300,207 @@ func buildQuery(tags []string, page int, pageSize int, authorName string, sortBy
 	return sb.String(), args
 }
 
-func buildCountQuery(tags []string, authorName string, minBookmarkCount *int, maxBookmarkCount *int, isBookmarked *bool) (string, []interface{}) {
+func buildCountQuery(req structs.SearchRequest) (string, []interface{}) {
 	var countSb strings.Builder
-	var args []interface{}
-	var whereConditions []string
-	var joinClauses []string
 
-	hasTags := len(tags) > 0
+	countSb.WriteString("SELECT COUNT(i.id) FROM image i ")
 
-	if hasTags {
-		countSb.WriteString("SELECT COUNT(*) FROM (SELECT 1 FROM image i ")
-	} else {
-		countSb.WriteString("SELECT COUNT(i.id) FROM image i ")
+	joinClauses, whereConditions, args := buildSearchConditions(req)
+	if len(joinClauses) > 0 {
+		countSb.WriteString(strings.Join(joinClauses, ""))
 	}
 
-	if authorName != "" {
-		joinClauses = append(joinClauses, " JOIN author a ON i.author_id = a.id ")
-	}
-	if hasTags {
-		joinClauses = append(joinClauses, " JOIN image_tag it ON i.pid = it.image_id ")
-		joinClauses = append(joinClauses, " JOIN tag t ON it.tag_id = t.id ")
+	if len(whereConditions) > 0 {
+		countSb.WriteString(" WHERE ")
+		countSb.WriteString(strings.Join(whereConditions, " AND "))
 	}
+	log.Debug().Str("构造字符串", countSb.String()).Msg("字符串输出")
+	return countSb.String(), args
+}
 
+// IsUnfilteredSearch 判断搜索条件是否会匹配全部作品，分页和排序不算筛选条件
+func IsUnfilteredSearch(req structs.SearchRequest) bool {
+	query, _ := buildPidQuery(req)
+	allQuery, _ := buildPidQuery(structs.SearchRequest{})
+	return query == allQuery
+}
+
+// buildPidQuery 生成只返回匹配作品 pid 的查询，不分页不排序，可作为子查询使用
+func buildPidQuery(req structs.SearchRequest) (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString("SELECT i.pid FROM image i ")
+
+	joinClauses, whereConditions, args := buildSearchConditions(req)
 	if len(joinClauses) > 0 {
-		if hasTags {
-			countSb.WriteString(strings.Join(joinClauses, ""))
-		} else {
-			countSb.WriteString(strings.Join(joinClauses, ""))
-		}
+		sb.WriteString(strings.Join(joinClauses, ""))
+	}
+	if len(whereConditions) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(whereConditions, " AND "))
+	}
+	return sb.String(), args
+}
+
+// buildSearchConditions 生成搜索和计数共用的 JOIN 与 WHERE 条件，image 表的别名为 i
+func buildSearchConditions(req structs.SearchRequest) ([]string, []string, []interface{}) {
+	var args []interface{}
+	var whereConditions []string
+	var joinClauses []string
+
+	// JOIN 写在 WHERE 前面，参数也要先于 WHERE 条件的参数加入
+	if req.CollectionID != nil {
+		joinClauses = append(joinClauses, " JOIN collection_image ci ON ci.image_id = i.pid AND ci.collection_id = ? ")
+		args = append(args, *req.CollectionID)
 	}
 
 	whereConditions = append(whereConditions, "i.url_regular IS NOT NULL")
 
-	if authorName != "" {
-		whereConditions = append(whereConditions, "a.name = ?")
-		args = append(args, authorName)
+	if req.Author != "" {
+		whereConditions = append(whereConditions, "i.author_id IN ("+authorIdsByNameSubquery+")")
+		args = append(args, req.Author, req.Author)
 	}
-	if minBookmarkCount != nil {
+	if req.MinBookmarkCount != nil {
 		whereConditions = append(whereConditions, "i.bookmark_count >= ?")
-		args = append(args, *minBookmarkCount)
+		args = append(args, *req.MinBookmarkCount)
 	}
-	if maxBookmarkCount != nil {
+	if req.MaxBookmarkCount != nil {
 		whereConditions = append(whereConditions, "i.bookmark_count <= ?")
-		args = append(args, *maxBookmarkCount)
+		args = append(args, *req.MaxBookmarkCount)
 	}
-	if isBookmarked != nil {
+	if req.IsBookmarked != nil {
 		whereConditions = append(whereConditions, "i.is_bookmarked = ?")
-		args = append(args, *isBookmarked)
+		args = append(args, *req.IsBookmarked)
 	}
-	if hasTags {
-		whereConditions = append(whereConditions, "t.name IN ("+strings.Repeat("?,", len(tags)-1)+"?)")
-		for _, tag := range tags {
-			args = append(args, tag)
-		}
+	if req.MinViewCount != nil {
+		whereConditions = append(whereConditions, "i.view_count >= ?")
+		args = append(args, *req.MinViewCount)
 	}
-
-	if len(whereConditions) > 0 {
-		countSb.WriteString(" WHERE ")
-		countSb.WriteString(strings.Join(whereConditions, " AND "))
+	if req.MinLikeCount != nil {
+		whereConditions = append(whereConditions, "i.like_count >= ?")
+		args = append(args, *req.MinLikeCount)
 	}
-
-	if hasTags {
-		countSb.WriteString(" GROUP BY i.id ")
-		countSb.WriteString(" HAVING COUNT(DISTINCT t.id) = ? ")
-		args = append(args, len(tags))
-		countSb.WriteString(") AS matching_images")
+	if req.MinCommentCount != nil {
+		whereConditions = append(whereConditions, "i.comment_count >= ?")
+		args = append(args, *req.MinCommentCount)
 	}
-	log.Debug().Str("构造字符串", countSb.String()).Msg("字符串输出")
-	return countSb.String(), args
-}
-
-func GetAllPids() ([]int, error) {
-	rows, err := db.Query(`SELECT pid FROM image ORDER BY pid`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query pids from image table: %w", err)
+	if req.SeriesID != nil {
+		whereConditions = append(whereConditions, "i.series_id = ?")
+		args = append(args, *req.SeriesID)
 	}
-	defer rows.Close()
-
-	var pids []int
-	for rows.Next() {
-		var pid int
-		if err := rows.Scan(&pid); err != nil {
-			fmt.Printf("Warning: failed to scan pid: %v\n", err)
-			continue
+	if req.UploadDateFrom != nil {
+		whereConditions = append(whereConditions, "i.upload_date >= ?")
+		args = append(args, *req.UploadDateFrom)
+	}
+	if req.UploadDateTo != nil {
+		whereConditions = append(whereConditions, "i.upload_date <= ?")
+		args = append(args, *req.UploadDateTo)
+	}
+	if req.MinWidth != nil {
+		whereConditions = append(whereConditions, "i.width >= ?")
+		args = append(args, *req.MinWidth)
+	}
+	if req.MaxWidth != nil {
+		whereConditions = append(whereConditions, "i.width <= ?")
+		args = append(args, *req.MaxWidth)
+	}
+	if req.MinHeight != nil {
+		whereConditions = append(whereConditions, "i.height >= ?")
+		args = append(args, *req.MinHeight)
+	}
+	if req.MaxHeight != nil {
+		whereConditions = append(whereConditions, "i.height <= ?")
+		args = append(args, *req.MaxHeight)
+	}
+	if req.MinAspectRatio != nil {
+		whereConditions = append(whereConditions, "i.height > 0 AND CAST(i.width AS REAL) / i.height >= ?")
+		args = append(args, *req.MinAspectRatio)
+	}
+	if req.MaxAspectRatio != nil {
+		whereConditions = append(whereConditions, "i.height > 0 AND CAST(i.width AS REAL) / i.height <= ?")
+		args = append(args, *req.MaxAspectRatio)
+	}
+	// 0011 之前的旧数据元数据列为 NULL：页数和类型按单页插画处理，与 ExcludeAI 把未知当作非 AI 一致
+	if req.MultiPage != nil {
+		if *req.MultiPage {
+			whereConditions = append(whereConditions, "COALESCE(i.page_count, 1) > 1")
+		} else {
+			whereConditions = append(whereConditions, "COALESCE(i.page_count, 1) <= 1")
 		}
-		pids = append(pids, pid)
 	}
-
-	if err = rows.Err(); err != nil {
-		return pids, fmt.Errorf("error encountered during row iteration for pids: %w", err)
+	if len(req.IllustTypes) > 0 {
+		whereConditions = append(whereConditions, "COALESCE(i.illust_type, 0) IN ("+strings.Repeat("?,", len(req.IllustTypes)-1)+"?)")
+		for _, illustType := range req.IllustTypes {
+			args = append(args, illustType)
+		}
 	}
-
-	return pids, nil
+	if req.ExcludeAI {
+		// ai_type 为 NULL 的旧数据还没有抓到元数据，不当作 AI 作品排除
+		whereConditions = append(whereConditions, "COALESCE(i.ai_type, 0) != ?")
+		args = append(args, structs.AITypeGenerated)
+	}
+	// 分级未知的旧数据可能是 R-18，只要指定了分级条件就不返回，刷新元数据后才会出现
+	if len(req.XRestrict) > 0 {
+		whereConditions = append(whereConditions, "i.x_restrict IS NOT NULL AND i.x_restrict IN ("+strings.Repeat("?,", len(req.XRestrict)-1)+"?)")
+		for _, xRestrict := range req.XRestrict {
+			args = append(args, xRestrict)
+		}
+	}
+	if req.MaxXRestrict != nil {
+		whereConditions = append(whereConditions, "i.x_restrict IS NOT NULL AND i.x_restrict <= ?")
+		args = append(args, *req.MaxXRestrict)
+	}
+	if req.FollowedAuthor != nil {
+		whereConditions = append(whereConditions, "i.author_id IN (SELECT id FROM author WHERE COALESCE(is_followed, FALSE) = ?)")
+		args = append(args, *req.FollowedAuthor)
+	}
+	// 每个搜索词都要命中作品的某个 tag，搜索词可以是原名、别名或任一语言的翻译
+	for _, tag := range req.Tags {
+		subquery, tagArgs := tagIdsMatching(tag)
+		whereConditions = append(whereConditions, "EXISTS (SELECT 1 FROM image_tag it WHERE it.image_id = i.pid AND it.tag_id IN ("+subquery+"))")
+		args = append(args, tagArgs...)
+	}
+	return joinClauses, whereConditions, args
 }
 
-func GetPidsByBookmarkRange(minBookmarks int, maxBookmarks int) ([]int, error) {
-	query := `
-        SELECT pid 
-        FROM image 
-        WHERE bookmark_count >= ? AND bookmark_count <= ? 
-        ORDER BY pid
-    `
-
-	rows, err := db.Query(query, minBookmarks, maxBookmarks)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query pids by bookmark range [%d, %d]: %w", minBookmarks, maxBookmarks, err)
-	}
-	defer rows.Close()
-	var pids []int
-	for rows.Next() {
-		var pid int
-		if err := rows.Scan(&pid); err != nil {
-			fmt.Printf("Warning: failed to scan pid during bookmark range query: %v\n", err)
-			continue
+// GetExistingPids 返回 pids 中已经在 image 表里的那部分
+func GetExistingPids(pids []int) (map[int]bool, error) {
+	existing := make(map[int]bool)
+	const batchSize = 500
+	for start := 0; start < len(pids); start += batchSize {
+		end := start + batchSize
+		if end > len(pids) {
+			end = len(pids)
+		}
+		batch := pids[start:end]
+		args := make([]interface{}, len(batch))
+		for i, pid := range batch {
+			args[i] = pid
+		}
+		rows, err := db.Query("SELECT pid FROM image WHERE pid IN ("+strings.Repeat("?,", len(batch)-1)+"?)", args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query existing pids: %w", err)
+		}
+		for rows.Next() {
+			var pid int
+			if err := rows.Scan(&pid); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			existing[pid] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
 		}
-		pids = append(pids, pid)
 	}
-	if err = rows.Err(); err != nil {
-		return pids, fmt.Errorf("error encountered during row iteration for bookmark range query [%d, %d]: %w", minBookmarks, maxBookmarks, err)
+	return existing, nil
+}
+
+func SetImageLocal(pid int, local bool) error {
+	_, err := db.Exec("UPDATE image SET local = ? WHERE pid = ?", local, pid)
+	if err != nil {
+		return fmt.Errorf("failed to set local flag for pid %d: %w", pid, err)
 	}
-	return pids, nil
+	return nil
 }
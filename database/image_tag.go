@@ -1,9 +1,12 @@
 package database
 
-import "go_/structs"
+import (
+	"fmt"
+	"go_/structs"
+)
 
 func InsertImageTag(pid int, tagId int) error {
-	_, err := db.Exec("INSERT INTO image_tag(image_id,tag_id) VALUES(?,?) ", pid, tagId)
+	_, err := db.Exec("INSERT OR IGNORE INTO image_tag(image_id,tag_id) VALUES(?,?) ", pid, tagId)
 	if err != nil {
 		return err
 	}
@@ -13,7 +16,7 @@ func InsertImageTag(pid int, tagId int) error {
 func GetTagsByPid(pid int) ([]structs.Tag, error) {
 	var tags []structs.Tag
 	rows, err := db.Query(`
-		SELECT t.id,t.name,t.translate_name
+		SELECT t.id,t.name,t.translate_name,it.source
 		FROM tag t
 		INNER JOIN image_tag it ON t.id=it.tag_id
 		WHERE it.image_id=?
@@ -24,10 +27,125 @@ func GetTagsByPid(pid int) ([]structs.Tag, error) {
 	defer rows.Close()
 	for rows.Next() {
 		var tag structs.Tag
-		if err := rows.Scan(&tag.ID, &tag.Name, &tag.TranslateName); err != nil {
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.TranslateName, &tag.Source); err != nil {
 			return nil, err
 		}
 		tags = append(tags, tag)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	translations, err := getTagTranslationsByPid(pid)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tags {
+		tags[i].Translations = translations[tags[i].ID]
+	}
 	return tags, nil
 }
+
+// upsertLocalImageTag 把 tag 作为本地 tag 加到作品上，已有的 Pixiv 或蕴含 tag 改为本地，之后刷新不会被删除
+func upsertLocalImageTag(exec sqlExecutor, pid int, tagId int) error {
+	_, err := exec.Exec(`
+		INSERT INTO image_tag (image_id, tag_id, source) VALUES (?, ?, ?)
+		ON CONFLICT(image_id, tag_id) DO UPDATE SET source = excluded.source`, pid, tagId, structs.TagSourceLocal)
+	if err != nil {
+		return fmt.Errorf("failed to add local tag %d to pid %d: %w", tagId, pid, err)
+	}
+	return nil
+}
+
+// removeLocalImageTag 只删除本地 tag，Pixiv 同步的 tag 不受影响
+func removeLocalImageTag(exec sqlExecutor, pid int, tagId int) (int64, error) {
+	result, err := exec.Exec("DELETE FROM image_tag WHERE image_id = ? AND tag_id = ? AND source = ?", pid, tagId, structs.TagSourceLocal)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove local tag %d from pid %d: %w", tagId, pid, err)
+	}
+	return result.RowsAffected()
+}
+
+// reapplyTagImplications 删掉作品蕴含得到的 tag 后重新计算，用于作品的 tag 减少之后
+func reapplyTagImplications(exec sqlExecutor, pid int) error {
+	_, err := exec.Exec("DELETE FROM image_tag WHERE image_id = ? AND source = ?", pid, structs.TagSourceImplied)
+	if err != nil {
+		return fmt.Errorf("failed to clear implied tags for pid %d: %w", pid, err)
+	}
+	return applyTagImplications(exec, pid)
+}
+
+// UpdateLocalImageTags 给作品加上 addIds、去掉 removeIds 里的本地 tag，返回实际删除的行数
+func UpdateLocalImageTags(pid int, addIds []int, removeIds []int) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction for local tags of pid %d: %w", pid, err)
+	}
+	defer tx.Rollback()
+
+	removed, err := updateLocalImageTags(tx, pid, addIds, removeIds)
+	if err != nil {
+		return 0, err
+	}
+	return removed, tx.Commit()
+}
+
+func updateLocalImageTags(exec sqlExecutor, pid int, addIds []int, removeIds []int) (int64, error) {
+	for _, tagId := range addIds {
+		if err := upsertLocalImageTag(exec, pid, tagId); err != nil {
+			return 0, err
+		}
+	}
+	var removed int64
+	for _, tagId := range removeIds {
+		affected, err := removeLocalImageTag(exec, pid, tagId)
+		if err != nil {
+			return 0, err
+		}
+		removed += affected
+	}
+	if err := reapplyTagImplications(exec, pid); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// BulkUpdateLocalImageTags 对搜索条件匹配的所有作品批量增删本地 tag，匹配的作品在修改前确定，
+// 返回作品数和删除的行数
+func BulkUpdateLocalImageTags(req structs.SearchRequest, addIds []int, removeIds []int) (int, int64, error) {
+	pidQuery, pidArgs := buildPidQuery(req)
+	rows, err := db.Query(pidQuery, pidArgs...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query pids for bulk local tags: %w", err)
+	}
+	var pids []int
+	for rows.Next() {
+		var pid int
+		if err := rows.Scan(&pid); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		pids = append(pids, pid)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction for bulk local tags: %w", err)
+	}
+	defer tx.Rollback()
+
+	var removed int64
+	for _, pid := range pids {
+		affected, err := updateLocalImageTags(tx, pid, addIds, removeIds)
+		if err != nil {
+			return 0, 0, err
+		}
+		removed += affected
+	}
+	return len(pids), removed, tx.Commit()
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"go_/structs"
+	"strings"
+	"time"
+)
+
+func AddToInbox(pid int, source string) error {
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO inbox (image_id, source, added_at)
+		VALUES (?, ?, ?)`, pid, source, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to add pid %d to inbox: %w", pid, err)
+	}
+	return nil
+}
+
+// inboxRowScanner 让 scanImage 在作品列之后接着读取收件箱自己的列
+type inboxRowScanner struct {
+	rows *sql.Rows
+	item *structs.InboxItem
+}
+
+func (s inboxRowScanner) Scan(dest ...interface{}) error {
+	return s.rows.Scan(append(dest, &s.item.Source, &s.item.AddedAt, &s.item.Seen)...)
+}
+
+// GetInbox 分页返回收件箱，只包含 image 表里还存在的作品
+func GetInbox(includeSeen bool, page int, pageSize int) ([]structs.InboxItem, int, error) {
+	fromClause := " FROM inbox JOIN image i ON i.pid = inbox.image_id "
+	if !includeSeen {
+		fromClause += " WHERE inbox.seen = FALSE "
+	}
+	var total int
+	err := db.QueryRow("SELECT COUNT(*)" + fromClause).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count inbox: %w", err)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	rows, err := db.Query(`
+		SELECT `+imageColumns+`, inbox.source, inbox.added_at, inbox.seen`+fromClause+`
+		ORDER BY inbox.added_at DESC, inbox.image_id DESC
+		LIMIT ? OFFSET ?`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query inbox: %w", err)
+	}
+	var items []structs.InboxItem
+	for rows.Next() {
+		var item structs.InboxItem
+		item.Image, err = scanImage(inboxRowScanner{rows, &item})
+		if err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 先关闭 rows 再逐个补全作品详情，避免占用连接
+	for i := range items {
+		if err = fillImageDetails(&items[i].Image); err != nil {
+			return nil, 0, fmt.Errorf("failed to get inbox image %d: %w", items[i].Image.PID, err)
+		}
+	}
+	return items, total, nil
+}
+
+// MarkInboxSeen 把指定作品标记为已读，pids 为空时不做任何修改
+func MarkInboxSeen(pids []int) (int64, error) {
+	if len(pids) == 0 {
+		return 0, nil
+	}
+	args := make([]interface{}, 0, len(pids))
+	for _, pid := range pids {
+		args = append(args, pid)
+	}
+	result, err := db.Exec("UPDATE inbox SET seen = TRUE WHERE seen = FALSE AND image_id IN ("+strings.Repeat("?,", len(pids)-1)+"?)", args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark inbox seen: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// MarkAllInboxSeen 把收件箱里所有作品标记为已读
+func MarkAllInboxSeen() (int64, error) {
+	result, err := db.Exec("UPDATE inbox SET seen = TRUE WHERE seen = FALSE")
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark all inbox seen: %w", err)
+	}
+	return result.RowsAffected()
+}
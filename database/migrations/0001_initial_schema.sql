@@ -0,0 +1,55 @@
+-- 初始表结构，与 createTables 时代的数据库保持一致
+CREATE TABLE IF NOT EXISTS local_gallery (
+    id INTEGER PRIMARY KEY,
+    path TEXT
+);
+
+CREATE TABLE IF NOT EXISTS author (
+    id INTEGER PRIMARY KEY,
+    name TEXT,
+    uid TEXT
+);
+
+CREATE TABLE IF NOT EXISTS tag (
+    id INTEGER PRIMARY KEY,
+    name TEXT,
+    translate_name TEXT DEFAULT ""
+);
+
+CREATE TABLE IF NOT EXISTS image (
+    id INTEGER PRIMARY KEY,
+    pid INTEGER,
+    author_id INTEGER,
+    name TEXT,
+    bookmark_count INTEGER DEFAULT 0,
+    is_bookmarked BOOLEAN DEFAULT FALSE,
+    local BOOLEAN DEFAULT FALSE,
+    url_original TEXT,
+    url_mini TEXT,
+    url_thumb TEXT,
+    url_small TEXT,
+    url_regular TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (author_id) REFERENCES author(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS page (
+    id INTEGER PRIMARY KEY,
+    image_id INTEGER,
+    page_id INTEGER,
+    FOREIGN KEY (image_id) REFERENCES image(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS image_tag (
+    id INTEGER PRIMARY KEY,
+    image_id INTEGER,
+    tag_id INTEGER,
+    FOREIGN KEY (image_id) REFERENCES image(pid) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS configuration (
+    id INTEGER PRIMARY KEY,
+    key TEXT UNIQUE NOT NULL,
+    value TEXT
+);
@@ -0,0 +1,41 @@
+-- 去重后再建立唯一索引，否则已有数据库上的 CREATE UNIQUE INDEX 会失败
+
+-- image: 同一个 pid 只保留最早插入的一行，重复行上的 local 和 is_bookmarked 先合并到保留的行；
+-- pid 为 NULL 的行不受唯一索引限制，不做处理
+UPDATE image
+SET local = (SELECT MAX(COALESCE(i2.local, FALSE)) FROM image i2 WHERE i2.pid = image.pid),
+    is_bookmarked = (SELECT MAX(COALESCE(i2.is_bookmarked, FALSE)) FROM image i2 WHERE i2.pid = image.pid)
+WHERE pid IN (SELECT pid FROM image WHERE pid IS NOT NULL GROUP BY pid HAVING COUNT(*) > 1);
+
+DELETE FROM image
+WHERE pid IS NOT NULL
+  AND id NOT IN (SELECT MIN(id) FROM image WHERE pid IS NOT NULL GROUP BY pid);
+
+-- tag: 同名 tag 合并到 id 最小的一行，并改写 image_tag 的引用
+UPDATE image_tag
+SET tag_id = (
+    SELECT MIN(t2.id) FROM tag t2
+    WHERE t2.name = (SELECT t1.name FROM tag t1 WHERE t1.id = image_tag.tag_id)
+)
+WHERE tag_id IN (SELECT id FROM tag WHERE name IS NOT NULL);
+
+DELETE FROM tag
+WHERE name IS NOT NULL
+  AND id NOT IN (SELECT MIN(id) FROM tag WHERE name IS NOT NULL GROUP BY name);
+
+-- image_tag / page: 去掉重复的关联行
+DELETE FROM image_tag
+WHERE id NOT IN (SELECT MIN(id) FROM image_tag GROUP BY image_id, tag_id);
+
+DELETE FROM page
+WHERE id NOT IN (SELECT MIN(id) FROM page GROUP BY image_id, page_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_image_pid ON image(pid);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_name ON tag(name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_image_tag_image_tag ON image_tag(image_id, tag_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_page_image_page ON page(image_id, page_id);
+
+CREATE INDEX IF NOT EXISTS idx_image_author_id ON image(author_id);
+CREATE INDEX IF NOT EXISTS idx_image_bookmark_count ON image(bookmark_count);
+CREATE INDEX IF NOT EXISTS idx_image_tag_tag_id ON image_tag(tag_id);
+CREATE INDEX IF NOT EXISTS idx_author_name ON author(name);
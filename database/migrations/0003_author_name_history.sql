@@ -0,0 +1,42 @@
+-- 记录作者在 Pixiv 上用过的所有名字，author.name 始终是最近一次看到的名字
+CREATE TABLE IF NOT EXISTS author_name_history (
+    id INTEGER PRIMARY KEY,
+    author_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    first_seen_at INTEGER NOT NULL,
+    last_seen_at INTEGER NOT NULL,
+    FOREIGN KEY (author_id) REFERENCES author(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_author_name_history_author_name ON author_name_history(author_id, name);
+CREATE INDEX IF NOT EXISTS idx_author_name_history_name ON author_name_history(name);
+
+-- 已有作者的名字作为历史的起点。同一个 uid 的重复作者行先把名字都记到 id 最小的一行上，再合并
+INSERT OR IGNORE INTO author_name_history (author_id, name, first_seen_at, last_seen_at)
+SELECT COALESCE(keep.id, a.id), a.name, CAST(strftime('%s', 'now') AS INTEGER), CAST(strftime('%s', 'now') AS INTEGER)
+FROM author a
+LEFT JOIN (SELECT MIN(id) AS id, uid FROM author WHERE uid IS NOT NULL GROUP BY uid) keep ON keep.uid = a.uid
+WHERE a.name IS NOT NULL;
+
+-- author: 同一个 uid 合并到 id 最小的一行，名字取最近一次出现的名字
+UPDATE author
+SET name = (
+    SELECT a2.name FROM author a2
+    WHERE a2.uid = author.uid
+    ORDER BY a2.id DESC
+    LIMIT 1
+)
+WHERE uid IS NOT NULL;
+
+UPDATE image
+SET author_id = (
+    SELECT MIN(a2.id) FROM author a2
+    WHERE a2.uid = (SELECT a1.uid FROM author a1 WHERE a1.id = image.author_id)
+)
+WHERE author_id IN (SELECT id FROM author WHERE uid IS NOT NULL);
+
+DELETE FROM author
+WHERE uid IS NOT NULL
+  AND id NOT IN (SELECT MIN(id) FROM author WHERE uid IS NOT NULL GROUP BY uid);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_author_uid ON author(uid);
@@ -0,0 +1,9 @@
+-- 来自 ajax/user/:id 的作者资料
+ALTER TABLE author ADD COLUMN avatar_url TEXT DEFAULT '';
+ALTER TABLE author ADD COLUMN avatar_big_url TEXT DEFAULT '';
+ALTER TABLE author ADD COLUMN comment TEXT DEFAULT '';
+ALTER TABLE author ADD COLUMN webpage TEXT DEFAULT '';
+ALTER TABLE author ADD COLUMN social TEXT DEFAULT '{}';
+ALTER TABLE author ADD COLUMN is_followed BOOLEAN DEFAULT FALSE;
+ALTER TABLE author ADD COLUMN followed_back BOOLEAN DEFAULT FALSE;
+ALTER TABLE author ADD COLUMN profile_updated_at INTEGER;
@@ -0,0 +1,14 @@
+-- 收藏同步：收藏 id、公开/非公开、首次发现收藏的时间以及自己的收藏标签
+ALTER TABLE image ADD COLUMN bookmark_id TEXT;
+ALTER TABLE image ADD COLUMN bookmark_private BOOLEAN DEFAULT FALSE;
+ALTER TABLE image ADD COLUMN bookmarked_at INTEGER;
+
+CREATE TABLE IF NOT EXISTS image_bookmark_tag (
+    id INTEGER PRIMARY KEY,
+    image_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    FOREIGN KEY (image_id) REFERENCES image(pid) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_image_bookmark_tag_image_name ON image_bookmark_tag(image_id, name);
+CREATE INDEX IF NOT EXISTS idx_image_is_bookmarked ON image(is_bookmarked);
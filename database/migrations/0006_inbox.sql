@@ -0,0 +1,12 @@
+-- 从关注动态等来源新入库的作品，供“上次检查后的新作品”收件箱使用
+CREATE TABLE IF NOT EXISTS inbox (
+    id INTEGER PRIMARY KEY,
+    image_id INTEGER NOT NULL,
+    source TEXT NOT NULL,
+    added_at INTEGER NOT NULL,
+    seen BOOLEAN DEFAULT FALSE,
+    FOREIGN KEY (image_id) REFERENCES image(pid) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inbox_image_id ON inbox(image_id);
+CREATE INDEX IF NOT EXISTS idx_inbox_seen_added_at ON inbox(seen, added_at);
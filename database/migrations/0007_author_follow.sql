@@ -0,0 +1,6 @@
+-- 关注列表同步：is_followed 沿用 0004 中的列，这里补充关注时间和公开状态
+ALTER TABLE author ADD COLUMN follow_private BOOLEAN DEFAULT FALSE;
+ALTER TABLE author ADD COLUMN followed_at INTEGER;
+ALTER TABLE author ADD COLUMN unfollowed_at INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_author_is_followed ON author(is_followed);
@@ -0,0 +1,19 @@
+-- 定时任务，name 对应代码中注册的任务，默认全部关闭
+CREATE TABLE IF NOT EXISTS scheduled_task (
+    id INTEGER PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    cron TEXT NOT NULL,
+    enabled BOOLEAN DEFAULT FALSE,
+    last_run_at INTEGER,
+    last_finished_at INTEGER,
+    last_status TEXT DEFAULT '',
+    last_message TEXT DEFAULT '',
+    last_error TEXT DEFAULT ''
+);
+
+INSERT OR IGNORE INTO scheduled_task (name, cron) VALUES
+    ('update-all', '0 4 * * 0'),
+    ('checker', '0 5 * * *'),
+    ('follow-latest-sync', '0 * * * *'),
+    ('bookmark-sync', '30 3 * * *'),
+    ('gallery-rescan', '0 2 * * *');
@@ -0,0 +1,8 @@
+-- 记录刷新失败，供 failed 策略重新获取
+ALTER TABLE image ADD COLUMN refresh_failed_at INTEGER;
+ALTER TABLE image ADD COLUMN refresh_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_image_updated_at ON image(updated_at);
+
+INSERT OR IGNORE INTO scheduled_task (name, cron) VALUES
+    ('refresh-tiered', '0 3 * * *');
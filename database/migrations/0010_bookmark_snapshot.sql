@@ -0,0 +1,18 @@
+-- 每次刷新作品时追加一条收藏数快照
+CREATE TABLE IF NOT EXISTS bookmark_snapshot (
+    id INTEGER PRIMARY KEY,
+    pid INTEGER NOT NULL,
+    bookmark_count INTEGER NOT NULL,
+    captured_at INTEGER NOT NULL,
+    FOREIGN KEY (pid) REFERENCES image(pid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmark_snapshot_pid_captured_at ON bookmark_snapshot(pid, captured_at);
+CREATE INDEX IF NOT EXISTS idx_bookmark_snapshot_captured_at ON bookmark_snapshot(captured_at);
+
+-- 用现有的收藏数作为每个作品的第一条快照
+INSERT INTO bookmark_snapshot (pid, bookmark_count, captured_at)
+SELECT pid, COALESCE(bookmark_count, 0),
+       CASE WHEN typeof(updated_at) = 'integer' THEN updated_at ELSE CAST(strftime('%s', 'now') AS INTEGER) END
+FROM image
+WHERE pid IS NOT NULL;
@@ -0,0 +1,20 @@
+-- 作品的完整元数据：简介、创建/上传时间、类型、分级、AI 标记、尺寸、页数、浏览/点赞/评论数和系列信息
+-- illust_type: 0 插画 1 漫画 2 动图；x_restrict: 0 全年龄 1 R-18 2 R-18G；ai_type: 0 未知 1 非 AI 2 AI 生成
+ALTER TABLE image ADD COLUMN description TEXT;
+ALTER TABLE image ADD COLUMN create_date INTEGER;
+ALTER TABLE image ADD COLUMN upload_date INTEGER;
+ALTER TABLE image ADD COLUMN illust_type INTEGER;
+ALTER TABLE image ADD COLUMN x_restrict INTEGER;
+ALTER TABLE image ADD COLUMN ai_type INTEGER;
+ALTER TABLE image ADD COLUMN width INTEGER;
+ALTER TABLE image ADD COLUMN height INTEGER;
+ALTER TABLE image ADD COLUMN page_count INTEGER;
+ALTER TABLE image ADD COLUMN view_count INTEGER;
+ALTER TABLE image ADD COLUMN like_count INTEGER;
+ALTER TABLE image ADD COLUMN comment_count INTEGER;
+ALTER TABLE image ADD COLUMN series_id INTEGER;
+ALTER TABLE image ADD COLUMN series_title TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_image_upload_date ON image(upload_date);
+CREATE INDEX IF NOT EXISTS idx_image_create_date ON image(create_date);
+CREATE INDEX IF NOT EXISTS idx_image_series_id ON image(series_id);
@@ -0,0 +1,12 @@
+-- Pixiv 给每个 tag 返回按语言区分的翻译，tag.translate_name 保留一份首选语言的翻译方便展示
+CREATE TABLE IF NOT EXISTS tag_translation (
+    id INTEGER PRIMARY KEY,
+    tag_id INTEGER NOT NULL,
+    lang TEXT NOT NULL,
+    name TEXT NOT NULL,
+    FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_translation_tag_lang ON tag_translation(tag_id, lang);
+CREATE INDEX IF NOT EXISTS idx_tag_translation_name ON tag_translation(name COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_tag_translate_name ON tag(translate_name COLLATE NOCASE);
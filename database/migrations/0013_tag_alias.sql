@@ -0,0 +1,11 @@
+-- tag 别名：搜索或入库时遇到别名都按对应的规范 tag 处理
+CREATE TABLE IF NOT EXISTS tag_alias (
+    id INTEGER PRIMARY KEY,
+    alias TEXT NOT NULL,
+    tag_id INTEGER NOT NULL,
+    created_at INTEGER,
+    FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_alias_alias ON tag_alias(alias);
+CREATE INDEX IF NOT EXISTS idx_tag_alias_tag_id ON tag_alias(tag_id);
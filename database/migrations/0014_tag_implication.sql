@@ -0,0 +1,12 @@
+-- tag 蕴含规则：tag_id 的作品同时带上 implied_tag_id，比如 初音ミク -> VOCALOID，规则可以传递
+CREATE TABLE IF NOT EXISTS tag_implication (
+    id INTEGER PRIMARY KEY,
+    tag_id INTEGER NOT NULL,
+    implied_tag_id INTEGER NOT NULL,
+    created_at INTEGER,
+    FOREIGN KEY (tag_id) REFERENCES tag(id) ON DELETE CASCADE,
+    FOREIGN KEY (implied_tag_id) REFERENCES tag(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_implication_pair ON tag_implication(tag_id, implied_tag_id);
+CREATE INDEX IF NOT EXISTS idx_tag_implication_implied ON tag_implication(implied_tag_id);
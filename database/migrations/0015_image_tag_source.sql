@@ -0,0 +1,5 @@
+-- image_tag 的来源：pixiv 为 Pixiv 同步的 tag，local 为用户自己加的 tag，implied 为蕴含规则补上的 tag；
+-- 刷新作品时只替换 pixiv 和 implied 的行
+ALTER TABLE image_tag ADD COLUMN source TEXT NOT NULL DEFAULT 'pixiv';
+
+CREATE INDEX IF NOT EXISTS idx_image_tag_image_source ON image_tag(image_id, source);
@@ -0,0 +1,25 @@
+-- 收藏夹：手动整理的作品集合，position 为集合内的顺序，cover_pid 为空时用第一张作品做封面
+CREATE TABLE IF NOT EXISTS collection (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cover_pid INTEGER,
+    created_at INTEGER,
+    updated_at INTEGER
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_name ON collection(name);
+
+CREATE TABLE IF NOT EXISTS collection_image (
+    id INTEGER PRIMARY KEY,
+    collection_id INTEGER NOT NULL,
+    image_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    added_at INTEGER,
+    FOREIGN KEY (collection_id) REFERENCES collection(id) ON DELETE CASCADE,
+    FOREIGN KEY (image_id) REFERENCES image(pid) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_image_pair ON collection_image(collection_id, image_id);
+CREATE INDEX IF NOT EXISTS idx_collection_image_position ON collection_image(collection_id, position);
+CREATE INDEX IF NOT EXISTS idx_collection_image_image_id ON collection_image(image_id);
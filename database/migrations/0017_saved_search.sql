@@ -0,0 +1,11 @@
+-- 保存的搜索：request 为 SearchRequest 的 JSON，is_collection 为真时作为动态集合，成员在读取时计算
+CREATE TABLE IF NOT EXISTS saved_search (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    request TEXT NOT NULL,
+    is_collection BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at INTEGER,
+    updated_at INTEGER
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_search_name ON saved_search(name);
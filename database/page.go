@@ -52,8 +52,8 @@ func GetPageByPid(pid int) ([]structs.Page, error) {
 	return pages, nil
 }
 
-func UpdatePage(id, newPageNum int) error {
-	_, err := db.Exec("UPDATE page SET page_num = ? WHERE id = ?", newPageNum, id)
+func UpdatePage(id, newPageId int) error {
+	_, err := db.Exec("UPDATE page SET page_id = ? WHERE id = ?", newPageId, id)
 	return err
 }
 
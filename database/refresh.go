@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"go_/structs"
+	"time"
+)
+
+// staleCondition 判断 updated_at 是否早于 cutoff，旧数据里的 updated_at 可能是文本时间，一律视为过期
+const staleCondition = "(typeof(i.updated_at) != 'integer' OR i.updated_at < ?)"
+
+// GetPidsForRefresh 按策略选出需要重新获取的 pid，最久未更新的排在前面
+func GetPidsForRefresh(policy structs.RefreshPolicy, now time.Time) ([]int, error) {
+	var condition string
+	var args []interface{}
+	switch policy.Policy {
+	case structs.RefreshPolicyAll, "":
+		condition = "1 = 1"
+	case structs.RefreshPolicyStale:
+		condition = staleCondition
+		args = append(args, now.AddDate(0, 0, -policy.Days).Unix())
+	case structs.RefreshPolicyTiered:
+		condition = "((i.pid IN (SELECT pid FROM image ORDER BY pid DESC LIMIT ?) AND " + staleCondition + ") OR " + staleCondition + ")"
+		args = append(args, policy.RecentCount, now.AddDate(0, 0, -policy.RecentDays).Unix(), now.AddDate(0, 0, -policy.Days).Unix())
+	case structs.RefreshPolicyFailed:
+		condition = "i.refresh_failed_at IS NOT NULL"
+	case structs.RefreshPolicyChecker:
+		condition = "i.bookmark_count = 0"
+	case structs.RefreshPolicySearch:
+		if policy.Search == nil {
+			return nil, fmt.Errorf("refresh policy %s requires a search filter", policy.Policy)
+		}
+		pidQuery, pidArgs := buildPidQuery(*policy.Search)
+		condition = "i.pid IN (" + pidQuery + ")"
+		args = append(args, pidArgs...)
+	default:
+		return nil, fmt.Errorf("unknown refresh policy %s", policy.Policy)
+	}
+
+	query := "SELECT i.pid FROM image i WHERE " + condition + " ORDER BY typeof(i.updated_at) = 'integer', i.updated_at, i.pid"
+	if policy.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, policy.Limit)
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pids for refresh policy %s: %w", policy.Policy, err)
+	}
+	defer rows.Close()
+
+	var pids []int
+	for rows.Next() {
+		var pid int
+		if err := rows.Scan(&pid); err != nil {
+			return nil, err
+		}
+		pids = append(pids, pid)
+	}
+	return pids, rows.Err()
+}
+
+func RecordRefreshFailure(pid int, errMessage string) error {
+	_, err := db.Exec("UPDATE image SET refresh_failed_at = ?, refresh_error = ? WHERE pid = ?", time.Now().Unix(), errMessage, pid)
+	if err != nil {
+		return fmt.Errorf("failed to record refresh failure for pid %d: %w", pid, err)
+	}
+	return nil
+}
+
+func ClearRefreshFailure(pid int) error {
+	_, err := db.Exec("UPDATE image SET refresh_failed_at = NULL, refresh_error = NULL WHERE pid = ? AND refresh_failed_at IS NOT NULL", pid)
+	if err != nil {
+		return fmt.Errorf("failed to clear refresh failure for pid %d: %w", pid, err)
+	}
+	return nil
+}
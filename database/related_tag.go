@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"go_/structs"
+)
+
+var allowedRelatedTagSorts = map[string]string{
+	"count": "count DESC, lift DESC",
+	"lift":  "lift DESC, count DESC",
+}
+
+// GetRelatedTags 统计与搜索条件匹配的作品共现最多的 tag，搜索词本身命中的 tag 不计入，返回基准作品数
+func GetRelatedTags(req structs.SearchRequest, sortBy string, minCount int, limit int) (int, []structs.RelatedTag, error) {
+	orderBy, ok := allowedRelatedTagSorts[sortBy]
+	if !ok {
+		orderBy = allowedRelatedTagSorts["count"]
+	}
+	pidQuery, args := buildPidQuery(req)
+	// 全库计数和每个 tag 的全局次数都用不带条件的搜索范围，与基准作品数的范围一致
+	allPidQuery, allArgs := buildPidQuery(structs.SearchRequest{})
+
+	var baseCount, totalCount int
+	err := db.QueryRow("SELECT (SELECT COUNT(*) FROM ("+pidQuery+")), (SELECT COUNT(*) FROM ("+allPidQuery+"))", append(append([]interface{}{}, args...), allArgs...)...).Scan(&baseCount, &totalCount)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count images for related tags: %w", err)
+	}
+	if baseCount == 0 {
+		return 0, []structs.RelatedTag{}, nil
+	}
+
+	// 不能用 NULL 占位，NOT IN 遇到 NULL 结果恒为假
+	excluded := "SELECT 0"
+	var excludedArgs []interface{}
+	for _, tag := range req.Tags {
+		subquery, tagArgs := tagIdsMatching(tag)
+		excluded += " UNION SELECT id FROM (" + subquery + ")"
+		excludedArgs = append(excludedArgs, tagArgs...)
+	}
+
+	query := `
+		SELECT t.id, t.name, COALESCE(t.translate_name, ''), COUNT(*) AS count, g.global_count,
+		       CAST(COUNT(*) AS REAL) / ? AS support,
+		       (CAST(COUNT(*) AS REAL) / ?) / (CAST(g.global_count AS REAL) / ?) AS lift
+		FROM image_tag it
+		JOIN tag t ON t.id = it.tag_id
+		JOIN (SELECT tag_id, COUNT(*) AS global_count FROM image_tag WHERE image_id IN (` + allPidQuery + `) GROUP BY tag_id) g ON g.tag_id = t.id
+		WHERE it.image_id IN (` + pidQuery + `)
+		  AND t.id NOT IN (` + excluded + `)
+		GROUP BY t.id
+		HAVING COUNT(*) >= ?
+		ORDER BY ` + orderBy + `
+		LIMIT ?`
+	queryArgs := []interface{}{baseCount, baseCount, totalCount}
+	queryArgs = append(queryArgs, allArgs...)
+	queryArgs = append(queryArgs, args...)
+	queryArgs = append(queryArgs, excludedArgs...)
+	queryArgs = append(queryArgs, minCount, limit)
+
+	rows, err := db.Query(query, queryArgs...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query related tags: %w", err)
+	}
+	defer rows.Close()
+
+	related := []structs.RelatedTag{}
+	for rows.Next() {
+		var tag structs.RelatedTag
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.TranslateName, &tag.Count, &tag.GlobalCount, &tag.Support, &tag.Lift)
+		if err != nil {
+			return 0, nil, err
+		}
+		related = append(related, tag)
+	}
+	return baseCount, related, rows.Err()
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	jsoniter "github.com/json-iterator/go"
+	"go_/structs"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSavedSearchNotFound     = errors.New("saved search not found")
+	ErrSavedSearchNameConflict = errors.New("saved search name is already used")
+)
+
+func scanSavedSearch(row rowScanner) (structs.SavedSearch, error) {
+	var savedSearch structs.SavedSearch
+	var request string
+	err := row.Scan(&savedSearch.ID, &savedSearch.Name, &request, &savedSearch.IsCollection,
+		&savedSearch.CreatedAt, &savedSearch.UpdatedAt)
+	if err != nil {
+		return savedSearch, err
+	}
+	if err := jsoniter.UnmarshalFromString(request, &savedSearch.Request); err != nil {
+		return savedSearch, fmt.Errorf("failed to unmarshal request of saved search %d: %w", savedSearch.ID, err)
+	}
+	return savedSearch, nil
+}
+
+const savedSearchColumns = "id, name, request, is_collection, COALESCE(created_at, 0), COALESCE(updated_at, 0)"
+
+// GetSavedSearches 返回保存的搜索，onlyCollections 为真时只返回作为动态集合的
+func GetSavedSearches(onlyCollections bool) ([]structs.SavedSearch, error) {
+	rows, err := db.Query("SELECT "+savedSearchColumns+" FROM saved_search WHERE ? = FALSE OR is_collection ORDER BY name", onlyCollections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	savedSearches := []structs.SavedSearch{}
+	for rows.Next() {
+		savedSearch, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		savedSearches = append(savedSearches, savedSearch)
+	}
+	return savedSearches, rows.Err()
+}
+
+func GetSavedSearch(id int) (structs.SavedSearch, error) {
+	savedSearch, err := scanSavedSearch(db.QueryRow("SELECT "+savedSearchColumns+" FROM saved_search WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return savedSearch, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		return savedSearch, fmt.Errorf("failed to get saved search %d: %w", id, err)
+	}
+	return savedSearch, nil
+}
+
+func CreateSavedSearch(name string, req structs.SearchRequest, isCollection bool) (int, error) {
+	request, err := jsoniter.MarshalToString(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal saved search %q: %w", name, err)
+	}
+	now := time.Now().Unix()
+	result, err := db.Exec("INSERT INTO saved_search (name, request, is_collection, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		name, request, isCollection, now, now)
+	if isUniqueConstraintError(err) {
+		return 0, ErrSavedSearchNameConflict
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create saved search %q: %w", name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get id of saved search %q: %w", name, err)
+	}
+	return int(id), nil
+}
+
+// UpdateSavedSearch 修改 payload 中不为 nil 的字段
+func UpdateSavedSearch(id int, payload structs.SavedSearchPayload) error {
+	var sets []string
+	var args []interface{}
+	if payload.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, *payload.Name)
+	}
+	if payload.Request != nil {
+		request, err := jsoniter.MarshalToString(*payload.Request)
+		if err != nil {
+			return fmt.Errorf("failed to marshal saved search %d: %w", id, err)
+		}
+		sets = append(sets, "request = ?")
+		args = append(args, request)
+	}
+	if payload.IsCollection != nil {
+		sets = append(sets, "is_collection = ?")
+		args = append(args, *payload.IsCollection)
+	}
+	sets = append(sets, "updated_at = ?")
+	args = append(args, time.Now().Unix(), id)
+
+	result, err := db.Exec("UPDATE saved_search SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...)
+	if isUniqueConstraintError(err) {
+		return ErrSavedSearchNameConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved search %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+func DeleteSavedSearch(id int) error {
+	result, err := db.Exec("DELETE FROM saved_search WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// GetDynamicCollections 把作为集合的保存搜索转换为集合，作品数和封面（搜索结果第一张）在读取时计算
+func GetDynamicCollections() ([]structs.Collection, error) {
+	savedSearches, err := GetSavedSearches(true)
+	if err != nil {
+		return nil, err
+	}
+	collections := []structs.Collection{}
+	for _, savedSearch := range savedSearches {
+		collection, err := dynamicCollection(savedSearch)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, nil
+}
+
+// GetDynamicCollection 按保存搜索的 id 返回动态集合，保存的搜索不存在或没有作为集合时返回 ErrSavedSearchNotFound
+func GetDynamicCollection(savedSearchId int) (structs.Collection, error) {
+	savedSearch, err := GetSavedSearch(savedSearchId)
+	if err != nil {
+		return structs.Collection{}, err
+	}
+	if !savedSearch.IsCollection {
+		return structs.Collection{}, ErrSavedSearchNotFound
+	}
+	return dynamicCollection(savedSearch)
+}
+
+func dynamicCollection(savedSearch structs.SavedSearch) (structs.Collection, error) {
+	req := savedSearch.Request
+	req.Page = 1
+	req.PageSize = 1
+	images, count, err := SearchImages(req)
+	if err != nil {
+		return structs.Collection{}, fmt.Errorf("failed to evaluate saved search %d: %w", savedSearch.ID, err)
+	}
+	collection := structs.Collection{
+		Name:          savedSearch.Name,
+		ImageCount:    count,
+		CreatedAt:     savedSearch.CreatedAt,
+		UpdatedAt:     savedSearch.UpdatedAt,
+		Dynamic:       true,
+		SavedSearchID: savedSearch.ID,
+	}
+	if len(images) > 0 {
+		collection.CoverPID = images[0].PID
+		collection.CoverURLs = images[0].URLs
+	}
+	return collection, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"go_/structs"
+)
+
+const scheduledTaskColumns = `id, name, cron, enabled, last_run_at, last_finished_at, last_status, last_message, last_error`
+
+func scanScheduledTask(row interface{ Scan(...interface{}) error }) (structs.ScheduledTask, error) {
+	var task structs.ScheduledTask
+	var lastRunAt, lastFinishedAt sql.NullInt64
+	var lastStatus, lastMessage, lastError sql.NullString
+	err := row.Scan(&task.ID, &task.Name, &task.Cron, &task.Enabled, &lastRunAt, &lastFinishedAt, &lastStatus, &lastMessage, &lastError)
+	task.LastRunAt = lastRunAt.Int64
+	task.LastFinishedAt = lastFinishedAt.Int64
+	task.LastStatus = lastStatus.String
+	task.LastMessage = lastMessage.String
+	task.LastError = lastError.String
+	return task, err
+}
+
+func GetScheduledTasks() ([]structs.ScheduledTask, error) {
+	rows, err := db.Query("SELECT " + scheduledTaskColumns + " FROM scheduled_task ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []structs.ScheduledTask
+	for rows.Next() {
+		task, err := scanScheduledTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func GetScheduledTask(name string) (structs.ScheduledTask, error) {
+	row := db.QueryRow("SELECT "+scheduledTaskColumns+" FROM scheduled_task WHERE name = ?", name)
+	return scanScheduledTask(row)
+}
+
+func UpdateScheduledTask(name string, cron string, enabled bool) error {
+	result, err := db.Exec("UPDATE scheduled_task SET cron = ?, enabled = ? WHERE name = ?", cron, enabled, name)
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled task %s: %w", name, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func RecordScheduledTaskStart(name string, startedAt int64) error {
+	_, err := db.Exec("UPDATE scheduled_task SET last_run_at = ?, last_status = 'running' WHERE name = ?", startedAt, name)
+	if err != nil {
+		return fmt.Errorf("failed to record start of scheduled task %s: %w", name, err)
+	}
+	return nil
+}
+
+func RecordScheduledTaskResult(name string, finishedAt int64, status string, message string, errMessage string) error {
+	_, err := db.Exec(`
+		UPDATE scheduled_task
+		SET last_finished_at = ?, last_status = ?, last_message = ?, last_error = ?
+		WHERE name = ?`, finishedAt, status, message, errMessage, name)
+	if err != nil {
+		return fmt.Errorf("failed to record result of scheduled task %s: %w", name, err)
+	}
+	return nil
+}
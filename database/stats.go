@@ -0,0 +1,126 @@
+package database
+
+import (
+	"fmt"
+	"go_/structs"
+)
+
+// bookmarkBuckets 为收藏数直方图的分段，最后一段没有上限
+var bookmarkBuckets = []structs.BookmarkBucket{
+	{Min: 0, Max: 0},
+	{Min: 1, Max: 99},
+	{Min: 100, Max: 499},
+	{Min: 500, Max: 999},
+	{Min: 1000, Max: 4999},
+	{Min: 5000, Max: 9999},
+	{Min: 10000, Max: 49999},
+	{Min: 50000, Max: -1},
+}
+
+// GetLibraryStats 返回库的总量和分布，不包含本地图库占用和同步时间
+func GetLibraryStats() (structs.LibraryStats, error) {
+	var stats structs.LibraryStats
+	totals := &stats.Totals
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM image),
+			(SELECT COUNT(*) FROM page),
+			(SELECT COUNT(*) FROM author),
+			(SELECT COUNT(*) FROM author WHERE COALESCE(is_followed, FALSE)),
+			(SELECT COUNT(*) FROM tag),
+			(SELECT COUNT(*) FROM image WHERE COALESCE(local, FALSE)),
+			(SELECT COUNT(*) FROM image WHERE COALESCE(is_bookmarked, FALSE))`).Scan(
+		&totals.Images, &totals.Pages, &totals.Authors, &totals.FollowedAuthors,
+		&totals.Tags, &totals.LocalImages, &totals.BookmarkedImages)
+	if err != nil {
+		return stats, fmt.Errorf("failed to query library totals: %w", err)
+	}
+	totals.RemoteImages = totals.Images - totals.LocalImages
+
+	if stats.BookmarkHistogram, err = getBookmarkHistogram(); err != nil {
+		return stats, err
+	}
+	if stats.UploadMonths, err = getUploadMonthCounts(); err != nil {
+		return stats, err
+	}
+	if stats.IllustTypes, err = getIllustTypeCounts(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+func getBookmarkHistogram() ([]structs.BookmarkBucket, error) {
+	query := "SELECT"
+	var args []interface{}
+	for i, bucket := range bookmarkBuckets {
+		if i > 0 {
+			query += ","
+		}
+		if bucket.Max < 0 {
+			query += " COALESCE(SUM(bookmark_count >= ?), 0)"
+			args = append(args, bucket.Min)
+		} else {
+			query += " COALESCE(SUM(bookmark_count BETWEEN ? AND ?), 0)"
+			args = append(args, bucket.Min, bucket.Max)
+		}
+	}
+	query += " FROM image"
+
+	histogram := make([]structs.BookmarkBucket, len(bookmarkBuckets))
+	dest := make([]interface{}, len(bookmarkBuckets))
+	for i, bucket := range bookmarkBuckets {
+		histogram[i] = bucket
+		dest[i] = &histogram[i].Count
+	}
+	if err := db.QueryRow(query, args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to query bookmark histogram: %w", err)
+	}
+	return histogram, nil
+}
+
+// getUploadMonthCounts 按上传月份统计，还没有抓到上传时间的作品不计入
+func getUploadMonthCounts() ([]structs.MonthCount, error) {
+	rows, err := db.Query(`
+		SELECT strftime('%Y-%m', upload_date, 'unixepoch') AS month, COUNT(*)
+		FROM image
+		WHERE upload_date > 0
+		GROUP BY month
+		ORDER BY month`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query upload months: %w", err)
+	}
+	defer rows.Close()
+
+	months := []structs.MonthCount{}
+	for rows.Next() {
+		var month structs.MonthCount
+		if err := rows.Scan(&month.Month, &month.Count); err != nil {
+			return nil, err
+		}
+		months = append(months, month)
+	}
+	return months, rows.Err()
+}
+
+// getIllustTypeCounts 按作品类型统计，没有元数据的作品计为 -1
+func getIllustTypeCounts() ([]structs.IllustTypeCount, error) {
+	rows, err := db.Query(`
+		SELECT COALESCE(illust_type, -1) AS illust_type, COUNT(*)
+		FROM image
+		GROUP BY COALESCE(illust_type, -1)
+		ORDER BY illust_type`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query illust types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []structs.IllustTypeCount{}
+	for rows.Next() {
+		var illustType structs.IllustTypeCount
+		if err := rows.Scan(&illustType.IllustType, &illustType.Count); err != nil {
+			return nil, err
+		}
+		types = append(types, illustType)
+	}
+	return types, rows.Err()
+}
@@ -4,12 +4,23 @@ import (
 	"database/sql"
 	"fmt"
 	"go_/structs"
-	"sort"
+	"strings"
 )
 
-func GetOrCreateTagIdByName(name string) (int, error) {
+// GetTagIdByName 按名字取 tag id，名字是别名时返回规范 tag 的 id，都没有时返回 sql.ErrNoRows
+func GetTagIdByName(name string) (int, error) {
 	var id int
-	err := db.QueryRow("SELECT id FROM tag WHERE name = ?", name).Scan(&id)
+	err := db.QueryRow(`
+		SELECT id FROM tag WHERE name = ?
+		UNION ALL
+		SELECT tag_id FROM tag_alias WHERE alias = ?
+		LIMIT 1`, name, name).Scan(&id)
+	return id, err
+}
+
+// GetOrCreateTagIdByName 按名字取 tag id，名字是别名时返回规范 tag 的 id，都没有时新建
+func GetOrCreateTagIdByName(name string) (int, error) {
+	id, err := GetTagIdByName(name)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			result, err := db.Exec("INSERT INTO tag (name) VALUES (?)", name)
@@ -28,13 +39,18 @@ func GetOrCreateTagIdByName(name string) (int, error) {
 
 	return id, nil
 }
-func GetTags(page int, size int) ([]structs.Tag, error) {
+
+// GetTags 分页返回 tag，query 不为空时只返回原名、translate_name 或任一翻译包含 query 的 tag
+func GetTags(page int, size int, query string) ([]structs.Tag, error) {
 	offset := (page - 1) * size
+	pattern := "%" + query + "%"
 	rows, err := db.Query(`
 		SELECT id, name, translate_name 
 		FROM tag 
+		WHERE ? = '' OR name LIKE ? OR translate_name LIKE ?
+		   OR id IN (SELECT tag_id FROM tag_translation WHERE name LIKE ?)
 		ORDER BY id 
-		LIMIT ? OFFSET ?`, size, offset)
+		LIMIT ? OFFSET ?`, query, pattern, pattern, pattern, size, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -52,43 +68,107 @@ func GetTags(page int, size int) ([]structs.Tag, error) {
 	return tags, nil
 }
 
-func GetTagCounts() ([]structs.TagCount, error) {
+var allowedTagCountSortColumns = map[string]string{
+	"count": "count",
+	"name":  "t.name",
+	"id":    "t.id",
+}
+
+// GetTagCounts 统计搜索条件匹配的作品里每个 tag 的作品数，返回当前页和满足 min_count 的 tag 总数
+func GetTagCounts(req structs.StatisticsRequest) ([]structs.TagCount, int, error) {
+	pidQuery, args := buildPidQuery(req.Search)
+	grouped := `
+		SELECT t.id, t.name, COUNT(*) AS count
+		FROM image_tag it
+		JOIN tag t ON t.id = it.tag_id
+		WHERE it.image_id IN (` + pidQuery + `)
+		GROUP BY t.id
+		HAVING COUNT(*) >= ?`
+	args = append(args, req.MinCount)
 
-	query := `
-        SELECT tag.id,tag.name, COUNT(image_tag.tag_id) as count
-        FROM tag
-        INNER JOIN image_tag ON tag.id = image_tag.tag_id
-        GROUP BY tag.name;
-    `
-	rows, err := db.Query(query)
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM ("+grouped+")", args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tag statistics: %w", err)
+	}
+
+	sortColumn, ok := allowedTagCountSortColumns[req.SortBy]
+	if !ok {
+		sortColumn = "count"
+	}
+	query := grouped + " ORDER BY " + sortColumn + " " + statisticsSortOrder(req.SortOrder) + ", t.id"
+	query, args = appendStatisticsPaging(query, args, req)
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("failed to query tag statistics: %w", err)
 	}
 	defer rows.Close()
 
-	var tagCounts []structs.TagCount
+	tagCounts := []structs.TagCount{}
 	for rows.Next() {
 		var tagCount structs.TagCount
 		if err := rows.Scan(&tagCount.ID, &tagCount.Name, &tagCount.Count); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		tagCounts = append(tagCounts, tagCount)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+	return tagCounts, total, nil
+}
 
-	sort.Slice(tagCounts, func(i, j int) bool {
-		return tagCounts[i].Count > tagCounts[j].Count
-	})
+func statisticsSortOrder(sortOrder string) string {
+	order := strings.ToUpper(sortOrder)
+	if !allowedSortOrders[order] {
+		return "DESC"
+	}
+	return order
+}
 
-	return tagCounts, nil
+// appendStatisticsPaging 追加 LIMIT/OFFSET，PageSize 不大于 0 时与作品搜索一样每页 20 条
+func appendStatisticsPaging(query string, args []interface{}, req structs.StatisticsRequest) (string, []interface{}) {
+	page := req.Page
+	pageSize := req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return query + " LIMIT ? OFFSET ?", append(args, pageSize, (page-1)*pageSize)
 }
 
+// DeleteImageTags 删除作品从 Pixiv 同步和蕴含得到的 tag，本地 tag 保留
 func DeleteImageTags(pid int) error {
-	_, err := db.Exec(`DELETE FROM image_tag WHERE image_id = ?`, pid)
+	_, err := db.Exec(`DELETE FROM image_tag WHERE image_id = ? AND source != ?`, pid, structs.TagSourceLocal)
 	if err != nil {
 		return fmt.Errorf("failed to delete tags for pid %d: %w", pid, err)
 	}
 	return nil
 }
+
+// GetTagSuggestions 返回所有 tag 及其作品数，按作品数从多到少排序，供自动补全建索引
+func GetTagSuggestions() ([]structs.TagSuggestion, error) {
+	rows, err := db.Query(`
+		SELECT t.id, t.name, COALESCE(t.translate_name, ''), COUNT(it.image_id) AS count
+		FROM tag t
+		LEFT JOIN image_tag it ON it.tag_id = t.id
+		WHERE t.name IS NOT NULL
+		GROUP BY t.id
+		ORDER BY count DESC, t.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []structs.TagSuggestion
+	for rows.Next() {
+		var suggestion structs.TagSuggestion
+		if err := rows.Scan(&suggestion.ID, &suggestion.Name, &suggestion.TranslateName, &suggestion.Count); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
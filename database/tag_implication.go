@@ -0,0 +1,221 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_/structs"
+	"time"
+)
+
+var ErrTagImplicationCycle = errors.New("tag implication would create a cycle")
+
+// tagImplicationClosure 是 (tag_id, ancestor) 的传递闭包，UNION 去重保证脏数据里有环时也能结束
+const tagImplicationClosure = `
+	closure(tag_id, ancestor) AS (
+		SELECT tag_id, implied_tag_id FROM tag_implication
+		UNION
+		SELECT c.tag_id, ti.implied_tag_id FROM closure c JOIN tag_implication ti ON ti.tag_id = c.ancestor
+	)`
+
+// AddTagImplication 添加 tagId 蕴含 impliedTagId 的规则，会形成环时返回 ErrTagImplicationCycle。
+// 先插入再检查环，插入时就拿到写锁，并发添加 A→B 和 B→A 时后一个会看到前一个并失败
+func AddTagImplication(tagId int, impliedTagId int) (int, error) {
+	if tagId == impliedTagId {
+		return 0, ErrTagImplicationCycle
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction for tag implication %d -> %d: %w", tagId, impliedTagId, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO tag_implication (tag_id, implied_tag_id, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(tag_id, implied_tag_id) DO NOTHING`, tagId, impliedTagId, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert tag implication %d -> %d: %w", tagId, impliedTagId, err)
+	}
+	var cyclic bool
+	err = tx.QueryRow(`
+		WITH RECURSIVE up(id) AS (
+			SELECT ?
+			UNION
+			SELECT ti.implied_tag_id FROM tag_implication ti JOIN up ON ti.tag_id = up.id
+		)
+		SELECT EXISTS(SELECT 1 FROM up WHERE id = ?)`, impliedTagId, tagId).Scan(&cyclic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check implication cycle for %d -> %d: %w", tagId, impliedTagId, err)
+	}
+	if cyclic {
+		return 0, ErrTagImplicationCycle
+	}
+	var id int
+	err = tx.QueryRow("SELECT id FROM tag_implication WHERE tag_id = ? AND implied_tag_id = ?", tagId, impliedTagId).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tag implication %d -> %d: %w", tagId, impliedTagId, err)
+	}
+	return id, tx.Commit()
+}
+
+// DeleteTagImplication 删除规则，并在同一个事务里清掉所有蕴含得到的作品 tag 再按剩下的规则重新补上
+func DeleteTagImplication(id int) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction for deleting tag implication %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM tag_implication WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete tag implication %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if _, err = tx.Exec("DELETE FROM image_tag WHERE source = ?", structs.TagSourceImplied); err != nil {
+		return false, fmt.Errorf("failed to clear implied image tags: %w", err)
+	}
+	if _, err = applyAllTagImplications(tx); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func GetTagImplications() ([]structs.TagImplication, error) {
+	rows, err := db.Query(`
+		SELECT ti.id, ti.tag_id, t.name, ti.implied_tag_id, p.name, COALESCE(ti.created_at, 0)
+		FROM tag_implication ti
+		JOIN tag t ON t.id = ti.tag_id
+		JOIN tag p ON p.id = ti.implied_tag_id
+		ORDER BY p.name, t.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag implications: %w", err)
+	}
+	defer rows.Close()
+
+	implications := []structs.TagImplication{}
+	for rows.Next() {
+		var implication structs.TagImplication
+		err := rows.Scan(&implication.ID, &implication.TagID, &implication.TagName,
+			&implication.ImpliedTagID, &implication.ImpliedTagName, &implication.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		implications = append(implications, implication)
+	}
+	return implications, rows.Err()
+}
+
+// sqlExecutor 同时由 *sql.DB 和 *sql.Tx 实现
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// ApplyTagImplications 给作品补上已有 tag 蕴含的所有上级 tag
+func ApplyTagImplications(pid int) error {
+	return applyTagImplications(db, pid)
+}
+
+func applyTagImplications(exec sqlExecutor, pid int) error {
+	_, err := exec.Exec(`
+		WITH RECURSIVE `+tagImplicationClosure+`
+		INSERT OR IGNORE INTO image_tag (image_id, tag_id, source)
+		SELECT it.image_id, c.ancestor, ?
+		FROM image_tag it
+		JOIN closure c ON c.tag_id = it.tag_id
+		WHERE it.image_id = ?`, structs.TagSourceImplied, pid)
+	if err != nil {
+		return fmt.Errorf("failed to apply tag implications for pid %d: %w", pid, err)
+	}
+	return nil
+}
+
+// ApplyAllTagImplications 对所有作品应用蕴含规则，返回新增的 image_tag 行数
+func ApplyAllTagImplications() (int64, error) {
+	return applyAllTagImplications(db)
+}
+
+func applyAllTagImplications(exec sqlExecutor) (int64, error) {
+	result, err := exec.Exec(`
+		WITH RECURSIVE `+tagImplicationClosure+`
+		INSERT OR IGNORE INTO image_tag (image_id, tag_id, source)
+		SELECT it.image_id, c.ancestor, ?
+		FROM image_tag it
+		JOIN closure c ON c.tag_id = it.tag_id`, structs.TagSourceImplied)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply tag implications: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetTagTree 按蕴含规则构建 tag 树，rootId 大于 0 时只返回以它为根的子树，
+// 否则以所有不再蕴含其他 tag 的上级 tag 为根
+func GetTagTree(rootId int) ([]*structs.TagTreeNode, error) {
+	rows, err := db.Query(`
+		SELECT ti.tag_id, t.name, ti.implied_tag_id, p.name
+		FROM tag_implication ti
+		JOIN tag t ON t.id = ti.tag_id
+		JOIN tag p ON p.id = ti.implied_tag_id
+		ORDER BY t.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag implications for tree: %w", err)
+	}
+	names := make(map[int]string)
+	children := make(map[int][]int)
+	hasParent := make(map[int]bool)
+	var parentOrder []int
+	for rows.Next() {
+		var childId, parentId int
+		var childName, parentName string
+		if err := rows.Scan(&childId, &childName, &parentId, &parentName); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names[childId] = childName
+		names[parentId] = parentName
+		if _, ok := children[parentId]; !ok {
+			parentOrder = append(parentOrder, parentId)
+		}
+		children[parentId] = append(children[parentId], childId)
+		hasParent[childId] = true
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var build func(id int, path map[int]bool) *structs.TagTreeNode
+	build = func(id int, path map[int]bool) *structs.TagTreeNode {
+		node := &structs.TagTreeNode{ID: id, Name: names[id]}
+		path[id] = true
+		for _, childId := range children[id] {
+			if !path[childId] {
+				node.Children = append(node.Children, build(childId, path))
+			}
+		}
+		delete(path, id)
+		return node
+	}
+
+	if rootId > 0 {
+		tag, err := GetTagById(rootId)
+		if err != nil {
+			return nil, err
+		}
+		names[rootId] = tag.Name
+		return []*structs.TagTreeNode{build(rootId, make(map[int]bool))}, nil
+	}
+	roots := []*structs.TagTreeNode{}
+	for _, parentId := range parentOrder {
+		if !hasParent[parentId] {
+			roots = append(roots, build(parentId, make(map[int]bool)))
+		}
+	}
+	return roots, nil
+}
@@ -0,0 +1,305 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_/structs"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTagNotFound     = errors.New("tag not found")
+	ErrTagNameConflict = errors.New("tag name is already used by another tag or alias")
+	ErrTagInUse        = errors.New("tag is still used by images")
+)
+
+func GetTagById(id int) (structs.Tag, error) {
+	var tag structs.Tag
+	err := db.QueryRow("SELECT id, name, translate_name FROM tag WHERE id = ?", id).Scan(&tag.ID, &tag.Name, &tag.TranslateName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag, ErrTagNotFound
+	}
+	if err != nil {
+		return tag, fmt.Errorf("failed to get tag %d: %w", id, err)
+	}
+	return tag, nil
+}
+
+// tagNameTaken 检查 name 是否已经是 excludeId 以外某个 tag 的名字或别名
+func tagNameTaken(tx *sql.Tx, name string, excludeId int) (bool, error) {
+	var taken bool
+	err := tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM tag WHERE name = ? AND id != ?)
+		    OR EXISTS(SELECT 1 FROM tag_alias WHERE alias = ? AND tag_id != ?)`,
+		name, excludeId, name, excludeId).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("failed to check tag name %q: %w", name, err)
+	}
+	return taken, nil
+}
+
+// RenameTag 修改 tag 名，新名字已被其他 tag 使用时返回 ErrTagNameConflict，应改用合并；
+// 旧名字保留为别名，之后入库的同名 tag 仍归到这个 tag
+func RenameTag(id int, name string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for renaming tag %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRow("SELECT name FROM tag WHERE id = ?", id).Scan(&oldName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTagNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get tag %d: %w", id, err)
+	}
+	if oldName == name {
+		return nil
+	}
+	taken, err := tagNameTaken(tx, name, id)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTagNameConflict
+	}
+
+	if _, err = tx.Exec("DELETE FROM tag_alias WHERE alias = ?", name); err != nil {
+		return fmt.Errorf("failed to drop alias %q: %w", name, err)
+	}
+	if _, err = tx.Exec("UPDATE tag SET name = ? WHERE id = ?", name, id); err != nil {
+		return fmt.Errorf("failed to rename tag %d: %w", id, err)
+	}
+	if err = insertTagAlias(tx, oldName, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MergeTags 把 sourceIds 合并进 targetId：改写 image_tag，转移翻译和别名，
+// 来源 tag 的名字变成目标 tag 的别名，最后删除来源 tag
+func MergeTags(targetId int, sourceIds []int) error {
+	var sources []interface{}
+	for _, sourceId := range sourceIds {
+		if sourceId != targetId {
+			sources = append(sources, sourceId)
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	placeholders := strings.Repeat("?,", len(sources)-1) + "?"
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for merging into tag %d: %w", targetId, err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM tag WHERE id = ? OR id IN ("+placeholders+")", append([]interface{}{targetId}, sources...)...).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check tags for merge: %w", err)
+	}
+	if count != len(sources)+1 {
+		return ErrTagNotFound
+	}
+
+	targetFirst := append([]interface{}{targetId}, sources...)
+	statements := []string{
+		"INSERT OR IGNORE INTO image_tag (image_id, tag_id, source) SELECT image_id, ?, source FROM image_tag WHERE tag_id IN (" + placeholders + ")",
+		"INSERT OR IGNORE INTO tag_translation (tag_id, lang, name) SELECT ?, lang, name FROM tag_translation WHERE tag_id IN (" + placeholders + ")",
+		"UPDATE tag_alias SET tag_id = ? WHERE tag_id IN (" + placeholders + ")",
+		"UPDATE OR IGNORE tag_implication SET tag_id = ? WHERE tag_id IN (" + placeholders + ")",
+		"UPDATE OR IGNORE tag_implication SET implied_tag_id = ? WHERE implied_tag_id IN (" + placeholders + ")",
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement, targetFirst...); err != nil {
+			return fmt.Errorf("failed to merge tags into %d: %w", targetId, err)
+		}
+	}
+
+	rows, err := tx.Query("SELECT name FROM tag WHERE id IN ("+placeholders+")", sources...)
+	if err != nil {
+		return fmt.Errorf("failed to query source tag names: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	if _, err = tx.Exec("DELETE FROM image_tag WHERE tag_id IN ("+placeholders+")", sources...); err != nil {
+		return fmt.Errorf("failed to delete source image_tag rows: %w", err)
+	}
+	// 改写后重复或变成自身蕴含自身的规则直接删除
+	_, err = tx.Exec("DELETE FROM tag_implication WHERE tag_id = implied_tag_id OR tag_id IN ("+placeholders+") OR implied_tag_id IN ("+placeholders+")",
+		append(append([]interface{}{}, sources...), sources...)...)
+	if err != nil {
+		return fmt.Errorf("failed to delete source tag implications: %w", err)
+	}
+	if _, err = tx.Exec("DELETE FROM tag_translation WHERE tag_id IN ("+placeholders+")", sources...); err != nil {
+		return fmt.Errorf("failed to delete source tag translations: %w", err)
+	}
+	if _, err = tx.Exec("DELETE FROM tag WHERE id IN ("+placeholders+")", sources...); err != nil {
+		return fmt.Errorf("failed to delete source tags: %w", err)
+	}
+	for _, name := range names {
+		if err = insertTagAlias(tx, name, targetId); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertTagAlias(tx *sql.Tx, alias string, tagId int) error {
+	_, err := tx.Exec(`
+		INSERT INTO tag_alias (alias, tag_id, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(alias) DO UPDATE SET tag_id = excluded.tag_id`, alias, tagId, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save alias %q for tag %d: %w", alias, tagId, err)
+	}
+	return nil
+}
+
+// AddTagAlias 给 tag 增加别名，别名已是其他 tag 的名字或别名时返回 ErrTagNameConflict
+func AddTagAlias(tagId int, alias string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for tag alias: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name string
+	err = tx.QueryRow("SELECT name FROM tag WHERE id = ?", tagId).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTagNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get tag %d: %w", tagId, err)
+	}
+	taken, err := tagNameTaken(tx, alias, tagId)
+	if err != nil {
+		return err
+	}
+	if taken || alias == name {
+		return ErrTagNameConflict
+	}
+	if err = insertTagAlias(tx, alias, tagId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func DeleteTagAlias(alias string) (bool, error) {
+	result, err := db.Exec("DELETE FROM tag_alias WHERE alias = ?", alias)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete alias %q: %w", alias, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func GetTagAliases(tagId int) ([]structs.TagAlias, error) {
+	rows, err := db.Query("SELECT alias, tag_id, COALESCE(created_at, 0) FROM tag_alias WHERE tag_id = ? ORDER BY alias", tagId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases for tag %d: %w", tagId, err)
+	}
+	defer rows.Close()
+
+	aliases := []structs.TagAlias{}
+	for rows.Next() {
+		var alias structs.TagAlias
+		if err := rows.Scan(&alias.Alias, &alias.TagID, &alias.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// DeleteTag 删除没有作品使用的 tag，仍被使用时返回 ErrTagInUse
+func DeleteTag(id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for deleting tag %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM tag WHERE id = ? AND NOT EXISTS (SELECT 1 FROM image_tag WHERE tag_id = ?)", id, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var exists int
+		err = tx.QueryRow("SELECT 1 FROM tag WHERE id = ?", id).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTagNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get tag %d: %w", id, err)
+		}
+		return ErrTagInUse
+	}
+	if err = deleteOrphanTagRows(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteOrphanTagRows 清理指向已删除 tag 的翻译、别名和蕴含规则，数据库没有开启外键约束，不会级联删除
+func deleteOrphanTagRows(exec sqlExecutor) error {
+	if _, err := exec.Exec("DELETE FROM tag_translation WHERE tag_id NOT IN (SELECT id FROM tag)"); err != nil {
+		return fmt.Errorf("failed to delete orphan tag translations: %w", err)
+	}
+	if _, err := exec.Exec("DELETE FROM tag_alias WHERE tag_id NOT IN (SELECT id FROM tag)"); err != nil {
+		return fmt.Errorf("failed to delete orphan tag aliases: %w", err)
+	}
+	_, err := exec.Exec("DELETE FROM tag_implication WHERE tag_id NOT IN (SELECT id FROM tag) OR implied_tag_id NOT IN (SELECT id FROM tag)")
+	if err != nil {
+		return fmt.Errorf("failed to delete orphan tag implications: %w", err)
+	}
+	return nil
+}
+
+// DeleteUnusedTags 删除所有没有作品使用、没有别名指向也不在蕴含规则里的 tag，返回删除的数量
+func DeleteUnusedTags() (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction for deleting unused tags: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		DELETE FROM tag
+		WHERE NOT EXISTS (SELECT 1 FROM image_tag WHERE tag_id = tag.id)
+		  AND NOT EXISTS (SELECT 1 FROM tag_alias WHERE tag_id = tag.id)
+		  AND NOT EXISTS (SELECT 1 FROM tag_implication WHERE tag_id = tag.id OR implied_tag_id = tag.id)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete unused tags: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err = deleteOrphanTagRows(tx); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+)
+
+// preferredTranslationLangs 决定写入 tag.translate_name 的语言顺序，都没有时取语言代码最小的一个
+var preferredTranslationLangs = []string{"en", "zh", "zh_tw", "ko"}
+
+// SetTagTranslations 写入 tag 的各语言翻译并刷新 translate_name，已有的其他语言翻译保留。
+// name 是翻译所属的 Pixiv tag 名，它是 tagId 的别名时翻译属于别名，不覆盖规范 tag 的翻译
+func SetTagTranslations(tagId int, name string, translations map[string]string) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for tag %d translations: %w", tagId, err)
+	}
+	defer tx.Rollback()
+
+	var canonical string
+	if err = tx.QueryRow("SELECT name FROM tag WHERE id = ?", tagId).Scan(&canonical); err != nil {
+		return fmt.Errorf("failed to get tag %d: %w", tagId, err)
+	}
+	if canonical != name {
+		return nil
+	}
+
+	for lang, translation := range translations {
+		_, err = tx.Exec(`
+			INSERT INTO tag_translation (tag_id, lang, name) VALUES (?, ?, ?)
+			ON CONFLICT(tag_id, lang) DO UPDATE SET name = excluded.name`, tagId, lang, translation)
+		if err != nil {
+			return fmt.Errorf("failed to save %s translation for tag %d: %w", lang, tagId, err)
+		}
+	}
+	_, err = tx.Exec("UPDATE tag SET translate_name = ? WHERE id = ?", pickTranslateName(translations), tagId)
+	if err != nil {
+		return fmt.Errorf("failed to update translate_name for tag %d: %w", tagId, err)
+	}
+	return tx.Commit()
+}
+
+func pickTranslateName(translations map[string]string) string {
+	for _, lang := range preferredTranslationLangs {
+		if name, ok := translations[lang]; ok {
+			return name
+		}
+	}
+	langs := make([]string, 0, len(translations))
+	for lang := range translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return translations[langs[0]]
+}
+
+func GetTagTranslations(tagId int) (map[string]string, error) {
+	rows, err := db.Query("SELECT lang, name FROM tag_translation WHERE tag_id = ?", tagId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query translations for tag %d: %w", tagId, err)
+	}
+	defer rows.Close()
+
+	translations := make(map[string]string)
+	for rows.Next() {
+		var lang, name string
+		if err := rows.Scan(&lang, &name); err != nil {
+			return nil, err
+		}
+		translations[lang] = name
+	}
+	return translations, rows.Err()
+}
+
+// getTagTranslationsByPid 返回作品所有 tag 的翻译，按 tag id 分组
+func getTagTranslationsByPid(pid int) (map[int]map[string]string, error) {
+	rows, err := db.Query(`
+		SELECT tt.tag_id, tt.lang, tt.name
+		FROM tag_translation tt
+		INNER JOIN image_tag it ON it.tag_id = tt.tag_id
+		WHERE it.image_id = ?`, pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag translations for pid %d: %w", pid, err)
+	}
+	defer rows.Close()
+
+	translations := make(map[int]map[string]string)
+	for rows.Next() {
+		var tagId int
+		var lang, name string
+		if err := rows.Scan(&tagId, &lang, &name); err != nil {
+			return nil, err
+		}
+		if translations[tagId] == nil {
+			translations[tagId] = make(map[string]string)
+		}
+		translations[tagId][lang] = name
+	}
+	return translations, rows.Err()
+}
+
+// tagIdsMatching 返回原名、别名、translate_name 或任一语言翻译等于 term 的 tag id 子查询，翻译不区分大小写；
+// 结果还包含按蕴含规则属于这些 tag 的所有下级 tag
+func tagIdsMatching(term string) (string, []interface{}) {
+	return `
+	WITH RECURSIVE matched(id) AS (
+		SELECT id FROM (
+			SELECT id FROM tag WHERE name = ? OR translate_name = ? COLLATE NOCASE
+			UNION
+			SELECT tag_id FROM tag_alias WHERE alias = ?
+			UNION
+			SELECT tag_id FROM tag_translation WHERE name = ? COLLATE NOCASE
+		)
+		UNION
+		SELECT ti.tag_id FROM tag_implication ti JOIN matched m ON ti.implied_tag_id = m.id
+	)
+	SELECT id FROM matched`, []interface{}{term, term, term, term}
+}
@@ -1,19 +1,58 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 	"go_/database"
+	"strconv"
 )
 
 func getAuthorsWithCount(ctx *fiber.Ctx) error {
-	authors, err := database.GetAuthorImageCounts()
+	req, err := parseStatisticsRequest(ctx)
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	authors, total, err := database.GetAuthorImageCounts(req)
 	if err != nil {
 		log.Error().Msg(err.Error())
 		return sendCommonResponse(ctx, 500, err.Error(), nil)
 	}
 	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
-		"tags":  authors,
-		"total": len(authors),
+		"authors": authors,
+		"total":   total,
+	})
+}
+
+func getAuthors(ctx *fiber.Ctx) error {
+	page := ctx.QueryInt("page", 1)
+	pageSize := ctx.QueryInt("size", 20)
+	authors, total, err := database.GetAuthors(ctx.Query("name"), ctx.Query("sort_by"), ctx.Query("sort_order"), page, pageSize)
+	if err != nil {
+		log.Error().Err(err).Msg("查询作者列表出现错误")
+		return sendCommonResponse(ctx, 500, "查询作者列表出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"authors": authors,
+		"total":   total,
+	})
+}
+
+func getAuthorById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的作者 id", nil)
+	}
+	detail, err := database.GetAuthorDetail(id, ctx.QueryInt("tag_limit", 20))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sendCommonResponse(ctx, 404, "作者不存在", nil)
+		}
+		log.Error().Err(err).Int("id", id).Msg("查询作者详情出现错误")
+		return sendCommonResponse(ctx, 500, "查询作者详情出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"author": detail,
 	})
 }
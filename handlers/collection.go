@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"strconv"
+	"strings"
+)
+
+func sendCollectionError(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, database.ErrCollectionNotFound):
+		return sendCommonResponse(ctx, 404, "集合不存在", nil)
+	case errors.Is(err, database.ErrCollectionNameConflict):
+		return sendCommonResponse(ctx, 409, "集合名已存在", nil)
+	case errors.Is(err, database.ErrCollectionImageMissing):
+		return sendCommonResponse(ctx, 400, "作品不在集合中", nil)
+	}
+	log.Error().Err(err).Msg(message)
+	return sendCommonResponse(ctx, 500, message, nil)
+}
+
+func sendCollection(ctx *fiber.Ctx, id int) error {
+	collection, err := database.GetCollection(id)
+	if err != nil {
+		return sendCollectionError(ctx, err, "查询集合出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"collection": collection,
+	})
+}
+
+// getCollections 返回手动集合，dynamic=true 时追加由保存的搜索构成的动态集合
+func getCollections(ctx *fiber.Ctx) error {
+	collections, err := database.GetCollections()
+	if err != nil {
+		return sendCollectionError(ctx, err, "查询集合列表出现错误")
+	}
+	if ctx.QueryBool("dynamic", false) {
+		dynamic, err := database.GetDynamicCollections()
+		if err != nil {
+			return sendCollectionError(ctx, err, "查询动态集合出现错误")
+		}
+		collections = append(collections, dynamic...)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"collections": collections,
+		"total":       len(collections),
+	})
+}
+
+func getCollectionById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的集合 id", nil)
+	}
+	return sendCollection(ctx, id)
+}
+
+func createCollection(ctx *fiber.Ctx) error {
+	var payload structs.CollectionPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if payload.Name == nil || strings.TrimSpace(*payload.Name) == "" {
+		return sendCommonResponse(ctx, 400, "name 不能为空", nil)
+	}
+	var description string
+	if payload.Description != nil {
+		description = *payload.Description
+	}
+	id, err := database.CreateCollection(strings.TrimSpace(*payload.Name), description)
+	if err != nil {
+		return sendCollectionError(ctx, err, "创建集合出现错误")
+	}
+	return sendCollection(ctx, id)
+}
+
+func updateCollection(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的集合 id", nil)
+	}
+	var payload structs.CollectionPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if payload.Name != nil {
+		name := strings.TrimSpace(*payload.Name)
+		if name == "" {
+			return sendCommonResponse(ctx, 400, "name 不能为空", nil)
+		}
+		payload.Name = &name
+	}
+	if err := database.UpdateCollection(id, payload); err != nil {
+		return sendCollectionError(ctx, err, "修改集合出现错误")
+	}
+	return sendCollection(ctx, id)
+}
+
+func deleteCollection(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的集合 id", nil)
+	}
+	if err := database.DeleteCollection(id); err != nil {
+		return sendCollectionError(ctx, err, "删除集合出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", nil)
+}
+
+// parseCollectionImagesRequest 读取集合 id 和作品列表，参数无效时返回错误提示
+func parseCollectionImagesRequest(ctx *fiber.Ctx) (int, []int, string) {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return 0, nil, "无效的集合 id"
+	}
+	var payload structs.CollectionImagesPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return 0, nil, "解析请求体失败"
+	}
+	if len(payload.PIDs) == 0 {
+		return 0, nil, "pids 不能为空"
+	}
+	return id, payload.PIDs, ""
+}
+
+func addCollectionImages(ctx *fiber.Ctx) error {
+	id, pids, invalid := parseCollectionImagesRequest(ctx)
+	if invalid != "" {
+		return sendCommonResponse(ctx, 400, invalid, nil)
+	}
+	added, err := database.AddImagesToCollection(id, pids)
+	if err != nil {
+		return sendCollectionError(ctx, err, "添加作品到集合出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"added": added,
+	})
+}
+
+func removeCollectionImages(ctx *fiber.Ctx) error {
+	id, pids, invalid := parseCollectionImagesRequest(ctx)
+	if invalid != "" {
+		return sendCommonResponse(ctx, 400, invalid, nil)
+	}
+	removed, err := database.RemoveImagesFromCollection(id, pids)
+	if err != nil {
+		return sendCollectionError(ctx, err, "从集合移除作品出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"removed": removed,
+	})
+}
+
+// reorderCollection 把请求中的作品按顺序排到集合最前面
+func reorderCollection(ctx *fiber.Ctx) error {
+	id, pids, invalid := parseCollectionImagesRequest(ctx)
+	if invalid != "" {
+		return sendCommonResponse(ctx, 400, invalid, nil)
+	}
+	if err := database.ReorderCollection(id, pids); err != nil {
+		return sendCollectionError(ctx, err, "调整集合顺序出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", nil)
+}
+
+// getCollectionImages 按集合内顺序分页返回作品，需要其他筛选时用 POST /api/image 加 collection_id
+func getCollectionImages(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的集合 id", nil)
+	}
+	if _, err := database.GetCollection(id); err != nil {
+		return sendCollectionError(ctx, err, "查询集合出现错误")
+	}
+	req := structs.SearchRequest{
+		Page:         ctx.QueryInt("page", 1),
+		PageSize:     ctx.QueryInt("size", 20),
+		SortBy:       "position",
+		SortOrder:    "ASC",
+		CollectionID: &id,
+	}
+	images, total, err := database.SearchImages(req)
+	if err != nil {
+		return sendCollectionError(ctx, err, "查询集合作品出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"images": images,
+		"total":  total,
+	})
+}
+
+// getDynamicCollectionById 按保存搜索的 id 返回动态集合
+func getDynamicCollectionById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 id", nil)
+	}
+	collection, err := database.GetDynamicCollection(id)
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "查询动态集合出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"collection": collection,
+	})
+}
+
+// getDynamicCollectionImages 按保存的搜索条件分页返回动态集合的作品
+func getDynamicCollectionImages(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 id", nil)
+	}
+	savedSearch, err := database.GetSavedSearch(id)
+	if err == nil && !savedSearch.IsCollection {
+		err = database.ErrSavedSearchNotFound
+	}
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "查询动态集合出现错误")
+	}
+	req := savedSearch.Request
+	req.Page = ctx.QueryInt("page", 1)
+	req.PageSize = ctx.QueryInt("size", 20)
+	normalizeSearchRequest(&req)
+
+	images, total, err := database.SearchImages(req)
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "查询动态集合作品出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"images": images,
+		"total":  total,
+	})
+}
@@ -21,13 +21,72 @@ func InitHandlers(app *fiber.App) {
 	app.Post("/api/pixiv/cookie", updatePixivCookie)
 	app.Get("/api/pixiv/image/update", triggerUpdateAllHandler)
 	app.Get("/api/pixiv/image/checker", triggerUpdateAllHandlerChecker)
+	app.Post("/api/pixiv/image/refresh", postRefreshHandler)
 	app.Get("/api/pixiv/image/:pid", getImageByPid)
+	app.Get("/api/pixiv/image/:pid/recommend", getIllustRecommendations)
+	app.Get("/api/pixiv/image/:pid/bookmark-history", getBookmarkHistory)
+	app.Post("/api/pixiv/image/ingest", postIngestIllusts)
 	app.Post("/api/pixiv/image/following", postFollowLatestIllustsHandler)
+	app.Get("/api/pixiv/follow-latest/sync", triggerFollowLatestSync)
+	app.Get("/api/pixiv/inbox", getInbox)
+	app.Post("/api/pixiv/inbox/seen", postInboxSeen)
 	app.Post("/api/pixiv/usr/following", postFollowingUsersHandler)
+	app.Get("/api/pixiv/usr/following/sync", triggerFollowingSync)
+	app.Get("/api/pixiv/user/profile/update", triggerAuthorProfileRefresh)
+	app.Post("/api/pixiv/user/:uid/profile", refreshPixivUserProfile)
+	app.Post("/api/pixiv/user/:uid/sync", triggerPixivUserSync)
+	app.Get("/api/pixiv/bookmark/sync", triggerBookmarkSync)
+	app.Post("/api/pixiv/bookmark/:pid", postPixivBookmark)
+	app.Delete("/api/pixiv/bookmark/:pid", deletePixivBookmarkHandler)
 	app.Post("/api/image", searchImages)
+	app.Get("/api/image/trending", getTrendingImages)
+	app.Post("/api/image/tag/bulk", bulkUpdateLocalImageTags)
+	app.Post("/api/image/:pid/tag", addLocalImageTags)
+	app.Delete("/api/image/:pid/tag", removeLocalImageTags)
 	app.Post("/api/tag", getTagsWithPagination)
 	app.Get("/api/tag/tag-statistics", getTagsWithCount)
+	app.Post("/api/tag/tag-statistics", getTagsWithCount)
+	app.Get("/api/tag/suggest", getTagSuggestions)
+	app.Post("/api/tag/related", getRelatedTags)
+	app.Post("/api/tag/merge", mergeTags)
+	app.Get("/api/tag/tree", getTagTree)
+	app.Get("/api/tag/implication", getTagImplications)
+	app.Post("/api/tag/implication", addTagImplication)
+	app.Post("/api/tag/implication/apply", triggerApplyTagImplications)
+	app.Delete("/api/tag/implication/:id", deleteTagImplication)
+	app.Delete("/api/tag/unused", deleteUnusedTags)
+	app.Delete("/api/tag/alias/:alias", deleteTagAlias)
+	app.Put("/api/tag/:id", renameTag)
+	app.Delete("/api/tag/:id", deleteTag)
+	app.Get("/api/tag/:id/alias", getTagAliases)
+	app.Post("/api/tag/:id/alias", addTagAlias)
 	app.Get("/api/author/author-statistics", getAuthorsWithCount)
+	app.Post("/api/author/author-statistics", getAuthorsWithCount)
+	app.Get("/api/author", getAuthors)
+	app.Get("/api/author/:id", getAuthorById)
+	app.Get("/api/collection", getCollections)
+	app.Post("/api/collection", createCollection)
+	app.Get("/api/collection/dynamic/:id", getDynamicCollectionById)
+	app.Get("/api/collection/dynamic/:id/image", getDynamicCollectionImages)
+	app.Get("/api/collection/:id", getCollectionById)
+	app.Put("/api/collection/:id", updateCollection)
+	app.Delete("/api/collection/:id", deleteCollection)
+	app.Get("/api/collection/:id/image", getCollectionImages)
+	app.Post("/api/collection/:id/image", addCollectionImages)
+	app.Delete("/api/collection/:id/image", removeCollectionImages)
+	app.Put("/api/collection/:id/order", reorderCollection)
+	app.Get("/api/saved-search", getSavedSearches)
+	app.Post("/api/saved-search", createSavedSearch)
+	app.Get("/api/saved-search/:id", getSavedSearchById)
+	app.Put("/api/saved-search/:id", updateSavedSearch)
+	app.Delete("/api/saved-search/:id", deleteSavedSearch)
+	app.Get("/api/saved-search/:id/execute", executeSavedSearch)
+	app.Get("/api/stats", getLibraryStats)
+	app.Get("/api/job", getJobs)
+	app.Get("/api/job/:id", getJobById)
+	app.Get("/api/schedule", getScheduledTasks)
+	app.Put("/api/schedule/:name", updateScheduledTask)
+	app.Post("/api/schedule/:name/run", runScheduledTaskNow)
 
 }
 func sendCommonResponse(ctx *fiber.Ctx, code int, message string, data map[string]interface{}) error {
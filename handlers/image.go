@@ -5,24 +5,15 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 	"go_/database"
+	"go_/structs"
+	"strconv"
+	"time"
 )
 
 var ErrResponseBodyEmpty = errors.New("response body is empty or not a map")
 
-type SearchRequest struct {
-	Tags             []string `json:"tags"`
-	Page             int      `json:"page"`
-	PageSize         int      `json:"size"`
-	SortBy           string   `json:"sort_by"`
-	SortOrder        string   `json:"sort_order"`
-	Author           string   `json:"author"`
-	MinBookmarkCount *int     `json:"min_bookmark_count,omitempty"`
-	MaxBookmarkCount *int     `json:"max_bookmark_count,omitempty"`
-	IsBookmarked     *bool    `json:"is_bookmarked,omitempty"`
-}
-
 func searchImages(ctx *fiber.Ctx) error {
-	var req SearchRequest
+	var req structs.SearchRequest
 
 	if err := ctx.BodyParser(&req); err != nil {
 		rawBody := ctx.Body() // 读取原始 Body
@@ -33,6 +24,21 @@ func searchImages(ctx *fiber.Ctx) error {
 		})
 	}
 
+	normalizeSearchRequest(&req)
+	var count int
+	images, count, err := database.SearchImages(req)
+	if err != nil {
+		log.Error().Err(err)
+		return sendCommonResponse(ctx, 500, "查询图片出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"images": images,
+		"total":  count,
+	})
+}
+
+// normalizeSearchRequest 补上分页和排序的默认值
+func normalizeSearchRequest(req *structs.SearchRequest) {
 	if req.Page <= 0 {
 		req.Page = 1
 	}
@@ -48,14 +54,62 @@ func searchImages(ctx *fiber.Ctx) error {
 	if req.SortOrder == "" {
 		req.SortOrder = "DESC"
 	}
-	var count int
-	images, count, err := database.SearchImages(req.Tags, req.Page, req.PageSize, req.Author, req.SortBy, req.SortOrder, req.MinBookmarkCount, req.MaxBookmarkCount, req.IsBookmarked)
+}
+
+func getBookmarkHistory(ctx *fiber.Ctx) error {
+	pid, err := strconv.Atoi(ctx.Params("pid"))
 	if err != nil {
-		log.Error().Err(err)
-		return sendCommonResponse(ctx, 500, "查询图片出现错误", nil)
+		return sendCommonResponse(ctx, 400, "无效的 pid", nil)
+	}
+	var since int64
+	if days := ctx.QueryInt("days", 0); days > 0 {
+		since = time.Now().AddDate(0, 0, -days).Unix()
+	}
+	history, err := database.GetBookmarkHistory(pid, since)
+	if err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("查询收藏数历史出现错误")
+		return sendCommonResponse(ctx, 500, "查询收藏数历史出现错误", nil)
 	}
 	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
-		"images": images,
-		"total":  count,
+		"pid":     pid,
+		"history": history,
 	})
 }
+
+func getTrendingImages(ctx *fiber.Ctx) error {
+	days := ctx.QueryInt("days", 7)
+	if days <= 0 {
+		return sendCommonResponse(ctx, 400, "days 必须大于 0", nil)
+	}
+	limit := ctx.QueryInt("limit", 20)
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+	since := time.Now().AddDate(0, 0, -days).Unix()
+	trending, err := database.GetTrendingImages(since, limit)
+	if err != nil {
+		log.Error().Err(err).Int("days", days).Msg("查询收藏增长排行出现错误")
+		return sendCommonResponse(ctx, 500, "查询收藏增长排行出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"days":   days,
+		"images": trending,
+	})
+}
+
+// parseStatisticsRequest 读取统计接口的参数，POST 时从请求体读取搜索条件，GET 时只读取分页和排序参数
+func parseStatisticsRequest(ctx *fiber.Ctx) (structs.StatisticsRequest, error) {
+	var req structs.StatisticsRequest
+	if ctx.Method() == fiber.MethodPost {
+		if err := ctx.BodyParser(&req); err != nil {
+			return req, err
+		}
+		return req, nil
+	}
+	req.Page = ctx.QueryInt("page", 0)
+	req.PageSize = ctx.QueryInt("size", 0)
+	req.MinCount = ctx.QueryInt("min_count", 0)
+	req.SortBy = ctx.Query("sort_by")
+	req.SortOrder = ctx.Query("sort_order")
+	return req, nil
+}
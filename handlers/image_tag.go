@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"strconv"
+	"strings"
+)
+
+// resolveTagIds 把 tag 名转换为 id，create 为 false 时跳过不存在的名字
+func resolveTagIds(names []string, create bool) ([]int, error) {
+	var ids []int
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var id int
+		var err error
+		if create {
+			id, err = database.GetOrCreateTagIdByName(name)
+		} else {
+			id, err = database.GetTagIdByName(name)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func addLocalImageTags(ctx *fiber.Ctx) error {
+	return updateLocalImageTagsHandler(ctx, true)
+}
+
+func removeLocalImageTags(ctx *fiber.Ctx) error {
+	return updateLocalImageTagsHandler(ctx, false)
+}
+
+func updateLocalImageTagsHandler(ctx *fiber.Ctx, add bool) error {
+	pid, err := strconv.Atoi(ctx.Params("pid"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 pid", nil)
+	}
+	var payload structs.LocalTagPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if len(payload.Tags) == 0 {
+		return sendCommonResponse(ctx, 400, "tags 不能为空", nil)
+	}
+	exists, err := database.CheckPidExists(pid)
+	if err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("查询作品出现错误")
+		return sendCommonResponse(ctx, 500, "查询作品出现错误", nil)
+	}
+	if !exists {
+		return sendCommonResponse(ctx, 404, "作品不存在", nil)
+	}
+
+	tagIds, err := resolveTagIds(payload.Tags, add)
+	if err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("查询 tag 出现错误")
+		return sendCommonResponse(ctx, 500, "查询 tag 出现错误", nil)
+	}
+	var removed int64
+	if add {
+		_, err = database.UpdateLocalImageTags(pid, tagIds, nil)
+	} else {
+		removed, err = database.UpdateLocalImageTags(pid, nil, tagIds)
+	}
+	if err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("修改本地 tag 出现错误")
+		return sendCommonResponse(ctx, 500, "修改本地 tag 出现错误", nil)
+	}
+	markTagSuggestIndexDirty()
+
+	tags, err := database.GetTagsByPid(pid)
+	if err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("查询作品 tag 出现错误")
+		return sendCommonResponse(ctx, 500, "查询作品 tag 出现错误", nil)
+	}
+	data := map[string]interface{}{
+		"tags": tags,
+	}
+	if !add {
+		data["removed"] = removed
+	}
+	return sendCommonResponse(ctx, 200, "成功", data)
+}
+
+// bulkUpdateLocalImageTags 对搜索结果批量增删本地 tag，search 为空时需要 all 才会匹配全部作品
+func bulkUpdateLocalImageTags(ctx *fiber.Ctx) error {
+	var payload structs.BulkLocalTagPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if len(payload.Add) == 0 && len(payload.Remove) == 0 {
+		return sendCommonResponse(ctx, 400, "add 和 remove 不能都为空", nil)
+	}
+	if !payload.All && database.IsUnfilteredSearch(payload.Search) {
+		return sendCommonResponse(ctx, 400, "search 不能为空，修改全部作品需要指定 all", nil)
+	}
+	addIds, err := resolveTagIds(payload.Add, true)
+	if err != nil {
+		log.Error().Err(err).Msg("查询 tag 出现错误")
+		return sendCommonResponse(ctx, 500, "查询 tag 出现错误", nil)
+	}
+	removeIds, err := resolveTagIds(payload.Remove, false)
+	if err != nil {
+		log.Error().Err(err).Msg("查询 tag 出现错误")
+		return sendCommonResponse(ctx, 500, "查询 tag 出现错误", nil)
+	}
+	images, removed, err := database.BulkUpdateLocalImageTags(payload.Search, addIds, removeIds)
+	if err != nil {
+		log.Error().Err(err).Msg("批量修改本地 tag 出现错误")
+		return sendCommonResponse(ctx, 500, "批量修改本地 tag 出现错误", nil)
+	}
+	markTagSuggestIndexDirty()
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"images":  images,
+		"removed": removed,
+	})
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+
+	maxFinishedJobs = 100
+)
+
+// backgroundJob 是后台任务在内存中的状态，任务函数通过它汇报进度
+type backgroundJob struct {
+	mu  sync.Mutex
+	job structs.Job
+}
+
+func (j *backgroundJob) setTotal(total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.job.Total = total
+}
+
+func (j *backgroundJob) addTotal(delta int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.job.Total += delta
+}
+
+func (j *backgroundJob) setMessage(message string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.job.Message = message
+}
+
+// step 记录一项处理完成，err 不为 nil 时计入失败数
+func (j *backgroundJob) step(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.job.Processed++
+	if err != nil {
+		j.job.Failed++
+	}
+}
+
+func (j *backgroundJob) snapshot() structs.Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.job
+}
+
+var jobRegistry = struct {
+	sync.Mutex
+	nextID int
+	jobs   map[int]*backgroundJob
+}{jobs: make(map[int]*backgroundJob)}
+
+// jobSpec 描述一个后台任务，手动触发和定时任务用同一个 jobSpec，从而共用任务类型和 key，不会同时运行
+type jobSpec struct {
+	jobType string
+	key     string
+	run     func(job *backgroundJob) error
+}
+
+func (s jobSpec) start() (*backgroundJob, bool) {
+	return startJob(s.jobType, s.key, s.run)
+}
+
+// startJob 在后台运行 run。相同 jobType 和 key 的任务正在运行时不会重复启动，
+// 返回已有的任务且 started 为 false
+func startJob(jobType string, key string, run func(job *backgroundJob) error) (job *backgroundJob, started bool) {
+	jobRegistry.Lock()
+	for _, existing := range jobRegistry.jobs {
+		snapshot := existing.snapshot()
+		if snapshot.Type == jobType && snapshot.Key == key && snapshot.Status == JobStatusRunning {
+			jobRegistry.Unlock()
+			return existing, false
+		}
+	}
+	jobRegistry.nextID++
+	job = &backgroundJob{job: structs.Job{
+		ID:        jobRegistry.nextID,
+		Type:      jobType,
+		Key:       key,
+		Status:    JobStatusRunning,
+		StartedAt: time.Now().Unix(),
+	}}
+	jobRegistry.jobs[job.job.ID] = job
+	pruneFinishedJobs()
+	jobRegistry.Unlock()
+
+	go func() {
+		log.Info().Int("job_id", job.job.ID).Str("type", jobType).Str("key", key).Msg("后台任务开始")
+		err := run(job)
+		job.mu.Lock()
+		job.job.FinishedAt = time.Now().Unix()
+		if err != nil {
+			job.job.Status = JobStatusFailed
+			job.job.Error = err.Error()
+		} else {
+			job.job.Status = JobStatusSucceeded
+		}
+		job.mu.Unlock()
+		if err != nil {
+			log.Error().Err(err).Int("job_id", job.job.ID).Str("type", jobType).Msg("后台任务失败")
+		} else {
+			log.Info().Int("job_id", job.job.ID).Str("type", jobType).Msg("后台任务完成")
+			recordJobSuccess(jobType, job.job.FinishedAt)
+		}
+	}()
+	return job, true
+}
+
+// jobLastSuccessPrefix 加上任务类型作为 configuration 的 key，记录该类任务最近一次成功的时间
+const jobLastSuccessPrefix = "job_last_success:"
+
+func recordJobSuccess(jobType string, finishedAt int64) {
+	err := database.SetConfigValue(jobLastSuccessPrefix+jobType, strconv.FormatInt(finishedAt, 10))
+	if err != nil {
+		log.Warn().Err(err).Str("type", jobType).Msg("记录任务成功时间失败")
+	}
+}
+
+// pruneFinishedJobs 只保留最近的 maxFinishedJobs 个已结束任务，调用方需持有 jobRegistry 锁
+func pruneFinishedJobs() {
+	var finished []int
+	for id, job := range jobRegistry.jobs {
+		if job.snapshot().Status != JobStatusRunning {
+			finished = append(finished, id)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	sort.Ints(finished)
+	for _, id := range finished[:len(finished)-maxFinishedJobs] {
+		delete(jobRegistry.jobs, id)
+	}
+}
+
+func listJobs(jobType string) []structs.Job {
+	jobRegistry.Lock()
+	defer jobRegistry.Unlock()
+	jobs := []structs.Job{}
+	for _, job := range jobRegistry.jobs {
+		snapshot := job.snapshot()
+		if jobType == "" || snapshot.Type == jobType {
+			jobs = append(jobs, snapshot)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID > jobs[j].ID
+	})
+	return jobs
+}
+
+func sendJobStartedResponse(ctx *fiber.Ctx, job *backgroundJob, started bool) error {
+	message := "任务已启动"
+	if !started {
+		message = "相同的任务正在运行"
+	}
+	return sendCommonResponse(ctx, fiber.StatusAccepted, message, map[string]interface{}{
+		"job": job.snapshot(),
+	})
+}
+
+func getJobs(ctx *fiber.Ctx) error {
+	jobs := listJobs(ctx.Query("type"))
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"jobs":  jobs,
+		"total": len(jobs),
+	})
+}
+
+func getJobById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的任务 id", nil)
+	}
+	jobRegistry.Lock()
+	job, ok := jobRegistry.jobs[id]
+	jobRegistry.Unlock()
+	if !ok {
+		return sendCommonResponse(ctx, 404, "任务不存在", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"job": job.snapshot(),
+	})
+}
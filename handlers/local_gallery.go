@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
 	"go_/database"
 	"go_/structs"
 	"os"
@@ -47,35 +48,87 @@ func deleteGallery(ctx *fiber.Ctx) error {
 	return sendCommonResponse(ctx, 200, "删除成功", nil)
 }
 
-func initGallery(ctx *fiber.Ctx) error {
-	idParam := ctx.Params("id")
-	id, _ := strconv.Atoi(idParam)
-	folderPath, err := database.GetGalleryById(id)
-	err = filepath.Walk(folderPath.Path, func(path string, info os.FileInfo, err error) error {
+var galleryFilePattern = regexp.MustCompile(`(\d+)_p(\d+)\.(\w+)`)
+
+// scanGallery 把图库目录中 {pid}_p{page}.{ext} 形式的文件登记为本地页面。
+// refetch 为 true 时每个 pid 都重新从 Pixiv 获取一次，否则只获取图库中还没有的作品
+func scanGallery(job *backgroundJob, gallery structs.LocalGallery, refetch bool) error {
+	fetched := make(map[int]bool)
+	return filepath.Walk(gallery.Path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
-			re := regexp.MustCompile(`(\d+)_p(\d+)\.(\w+)`)
-			match := re.FindStringSubmatch(info.Name())
-			if match != nil {
-				pid, _ := strconv.Atoi(match[1])
-				pageId, _ := strconv.Atoi(match[2])
-				_, err := fetchPixivIllustDataFromPixiv(strconv.Itoa(pid), "http://localhost:7890")
-				if err != nil {
-					return sendCommonResponse(ctx, 500, "爬虫过程出现错误", nil)
-				}
-				_, err = database.InsertPageByPid(pid, pageId)
-				if err != nil {
-					return sendCommonResponse(ctx, 500, "爬虫过程出现错误", nil)
-				}
-			}
-			fmt.Println(info.Name())
+		if info.IsDir() {
+			return nil
 		}
+		match := galleryFilePattern.FindStringSubmatch(info.Name())
+		if match == nil {
+			return nil
+		}
+		pid, _ := strconv.Atoi(match[1])
+		pageId, _ := strconv.Atoi(match[2])
+		job.addTotal(1)
+		err = scanGalleryFile(pid, pageId, refetch, fetched)
+		if err != nil {
+			log.Error().Err(err).Str("file", path).Msg("登记本地图片失败")
+		}
+		job.step(err)
 		return nil
 	})
+}
+
+func scanGalleryFile(pid int, pageId int, refetch bool, fetched map[int]bool) error {
+	if !fetched[pid] {
+		exists, err := database.CheckPidExists(pid)
+		if err != nil {
+			return err
+		}
+		if refetch || !exists {
+			_, err = fetchPixivIllustDataFromPixiv(strconv.Itoa(pid), "http://localhost:7890")
+			if err != nil {
+				return err
+			}
+		}
+		fetched[pid] = true
+	}
+	_, err := database.InsertPageByPid(pid, pageId)
+	if err != nil {
+		return err
+	}
+	return database.SetImageLocal(pid, true)
+}
+
+// rescanAllGalleries 重新扫描所有图库，只获取新出现的作品
+func rescanAllGalleries(job *backgroundJob) error {
+	galleries, err := database.GetAllGalleries()
+	if err != nil {
+		return err
+	}
+	for _, gallery := range galleries {
+		if err := scanGallery(job, gallery, false); err != nil {
+			return fmt.Errorf("failed to scan gallery %s: %w", gallery.Path, err)
+		}
+	}
+	return nil
+}
+
+func initGallery(ctx *fiber.Ctx) error {
+	idParam := ctx.Params("id")
+	id, _ := strconv.Atoi(idParam)
+	gallery, err := database.GetGalleryById(id)
+	if err != nil {
+		return sendCommonResponse(ctx, 404, "图库不存在", nil)
+	}
+	job := &backgroundJob{}
+	err = scanGallery(job, gallery, true)
 	if err != nil {
 		return sendCommonResponse(ctx, 500, "遍历过程出现错误", nil)
 	}
+	if snapshot := job.snapshot(); snapshot.Failed > 0 {
+		return sendCommonResponse(ctx, 500, "爬虫过程出现错误", map[string]interface{}{
+			"processed": snapshot.Processed,
+			"failed":    snapshot.Failed,
+		})
+	}
 	return sendCommonResponse(ctx, 200, "完成初始化", nil)
 }
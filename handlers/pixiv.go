@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -157,12 +158,13 @@ func fetchPixivIllustDataFromPixiv(pid string, proxyStr string) (map[string]inte
 	exists, err := database.CheckPidExists(pidstr)
 	print(exists)
 	if err != nil {
-		return nil, fmt.Errorf("error checking pid %d existence: %w", pid, err)
+		return nil, fmt.Errorf("error checking pid %s existence: %w", pid, err)
 	}
 	name := getIllustInformationFromPixivIllust(pixivIllustData)
 	urls := getUrlsFromPixivIllust(pixivIllustData)
 	bookmarkCount := getBookmarkCountFromPixivIllust(pixivIllustData)
 	isBookmarked := getBookmarkFromPixivIllust(pixivIllustData)
+	meta := getIllustMetaFromPixivIllust(pixivIllustData)
 	authorInfo := structs.Author{
 		Name: getUserNameFromPixivIllust(pixivIllustData),
 		UID:  getUserIdFromPixivIllust(pixivIllustData),
@@ -171,36 +173,45 @@ func fetchPixivIllustDataFromPixiv(pid string, proxyStr string) (map[string]inte
 	author, err := database.GetOrCreateAuthor(authorInfo)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting or creating author for pid %d: %w", pid, err)
+		return nil, fmt.Errorf("error getting or creating author for pid %s: %w", pid, err)
 	}
 
 	if exists {
-		err = database.UpdateImage(pidstr, name, author.ID, bookmarkCount, isBookmarked, urls)
+		err = database.UpdateImage(pidstr, name, author.ID, bookmarkCount, isBookmarked, urls, meta)
 		if err != nil {
-			return nil, fmt.Errorf("error updating image record for pid %d: %w", pid, err)
+			return nil, fmt.Errorf("error updating image record for pid %s: %w", pid, err)
 		}
 		err = database.DeleteImageTags(pidstr)
 		if err != nil {
-			return nil, fmt.Errorf("error clearing old tags for pid %d: %w", pid, err)
+			return nil, fmt.Errorf("error clearing old tags for pid %s: %w", pid, err)
 		}
 	} else {
-		_, err = database.CreateImage(pidstr, name, author.ID, bookmarkCount, isBookmarked, urls)
+		_, err = database.CreateImage(pidstr, name, author.ID, bookmarkCount, isBookmarked, urls, meta)
 		if err != nil {
-			return nil, fmt.Errorf("error creating image record for pid %d: %w", pid, err)
+			return nil, fmt.Errorf("error creating image record for pid %s: %w", pid, err)
 		}
 	}
 
 	tags := getTagsFromPixivIllust(pixivIllustData)
+	translations := getTagTranslationsFromPixivIllust(pixivIllustData)
 	for _, tagName := range tags {
 		tid, err := database.GetOrCreateTagIdByName(tagName)
 		if err != nil {
-			return nil, fmt.Errorf("error getting or creating tag id for tag '%s' (pid %d): %w", tagName, pid, err)
+			return nil, fmt.Errorf("error getting or creating tag id for tag '%s' (pid %s): %w", tagName, pid, err)
 		}
 		err = database.InsertImageTag(pidstr, tid)
 		if err != nil {
-			return nil, fmt.Errorf("error inserting image-tag link for pid %d, tag id %d: %w", pid, tid, err)
+			return nil, fmt.Errorf("error inserting image-tag link for pid %s, tag id %d: %w", pid, tid, err)
 		}
+		err = database.SetTagTranslations(tid, tagName, translations[tagName])
+		if err != nil {
+			return nil, fmt.Errorf("error saving translations for tag '%s' (pid %s): %w", tagName, pid, err)
+		}
+	}
+	if err = database.ApplyTagImplications(pidstr); err != nil {
+		return nil, fmt.Errorf("error applying tag implications for pid %s: %w", pid, err)
 	}
+	markTagSuggestIndexDirty()
 	return pixivIllustData, nil
 }
 
@@ -216,6 +227,29 @@ func getTagsFromPixivIllust(result map[string]interface{}) []string {
 	return tagNames
 }
 
+// getTagTranslationsFromPixivIllust 读取每个 tag 的 translation 对象，返回 tag 名 -> 语言 -> 翻译
+func getTagTranslationsFromPixivIllust(result map[string]interface{}) map[string]map[string]string {
+	translations := make(map[string]map[string]string)
+	tags, _ := result["tags"].(map[string]interface{})
+	tagList, _ := tags["tags"].([]interface{})
+	for _, tagItem := range tagList {
+		tagMap, _ := tagItem.(map[string]interface{})
+		tagName, _ := tagMap["tag"].(string)
+		translationMap, _ := tagMap["translation"].(map[string]interface{})
+		for lang, value := range translationMap {
+			name, _ := value.(string)
+			if name == "" {
+				continue
+			}
+			if translations[tagName] == nil {
+				translations[tagName] = make(map[string]string)
+			}
+			translations[tagName][lang] = name
+		}
+	}
+	return translations
+}
+
 func getUrlsFromPixivIllust(result map[string]interface{}) structs.ImageURLs {
 	var extractedUrls structs.ImageURLs
 	urlsInterface, ok := result["urls"]
@@ -267,6 +301,37 @@ func getBookmarkCountFromPixivIllust(result map[string]interface{}) int {
 	bookmarkCount = int(result["bookmarkCount"].(float64))
 	return bookmarkCount
 }
+func getIllustMetaFromPixivIllust(result map[string]interface{}) structs.IllustMeta {
+	var meta structs.IllustMeta
+	meta.Description, _ = result["description"].(string)
+	meta.CreateDate = getUnixFromPixivDate(result["createDate"])
+	meta.UploadDate = getUnixFromPixivDate(result["uploadDate"])
+	meta.IllustType = getIntFromPixivField(result["illustType"])
+	meta.XRestrict = getIntFromPixivField(result["xRestrict"])
+	meta.AIType = getIntFromPixivField(result["aiType"])
+	meta.Width = getIntFromPixivField(result["width"])
+	meta.Height = getIntFromPixivField(result["height"])
+	meta.PageCount = getIntFromPixivField(result["pageCount"])
+	meta.ViewCount = getIntFromPixivField(result["viewCount"])
+	meta.LikeCount = getIntFromPixivField(result["likeCount"])
+	meta.CommentCount = getIntFromPixivField(result["commentCount"])
+	if series, ok := result["seriesNavData"].(map[string]interface{}); ok {
+		meta.SeriesID = getIntFromPixivField(series["seriesId"])
+		meta.SeriesTitle, _ = series["title"].(string)
+	}
+	return meta
+}
+
+// getUnixFromPixivDate 解析 Pixiv 的 ISO 8601 时间（如 2024-01-02T03:04:05+00:00），失败时返回 0
+func getUnixFromPixivDate(value interface{}) int64 {
+	dateStr, _ := value.(string)
+	t, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
+
 func getBookmarkFromPixivIllust(result map[string]interface{}) bool {
 	var isBookmarked bool
 	bookmarkDataValue, ok := result["bookmarkData"]
@@ -297,12 +362,12 @@ func getImageByPid(ctx *fiber.Ctx) error {
 	return sendCommonResponse(ctx, http.StatusOK, "成功", pixivData)
 }
 
-func fetchPixivFollowingFromPixiv(userID string, offset int, limit int) (map[string]interface{}, error) {
+func fetchPixivFollowingFromPixiv(userID string, offset int, limit int, rest string) (map[string]interface{}, error) {
 	baseURL := fmt.Sprintf("https://www.pixiv.net/ajax/user/%s/following", userID)
 	params := url.Values{}
 	params.Add("offset", strconv.Itoa(offset))
 	params.Add("limit", strconv.Itoa(limit))
-	params.Add("rest", "show")
+	params.Add("rest", rest)
 	params.Add("tag", "")
 	params.Add("acceptingRequests", "0")
 	params.Add("lang", "zh")
@@ -366,6 +431,7 @@ type FollowingRequestPayload struct {
 	UserID string `json:"userID"`
 	Offset *int   `json:"offset"`
 	Limit  *int   `json:"limit"`
+	Rest   string `json:"rest"`
 }
 
 func postFollowingUsersHandler(ctx *fiber.Ctx) error {
@@ -402,9 +468,14 @@ func postFollowingUsersHandler(ctx *fiber.Ctx) error {
 		}
 	}
 
-	log.Info().Str("userID", userID).Int("offset", offset).Int("limit", limit).Msg("Handler: Processing request for user following list")
+	rest := "show"
+	if payload.Rest == "hide" {
+		rest = "hide"
+	}
 
-	pixivData, err := fetchPixivFollowingFromPixiv(userID, offset, limit)
+	log.Info().Str("userID", userID).Int("offset", offset).Int("limit", limit).Str("rest", rest).Msg("Handler: Processing request for user following list")
+
+	pixivData, err := fetchPixivFollowingFromPixiv(userID, offset, limit, rest)
 
 	if err != nil {
 		log.Error().Err(err).Str("userID", userID).Msg("Handler: Error received from fetchPixivFollowingFromPixiv")
@@ -490,10 +561,11 @@ func fetchFollowLatestIllustsFromPixiv(page int, mode, lang, userID string) (map
 }
 
 type FollowLatestRequestPayload struct {
-	UserID string  `json:"userID"`
-	Page   *int    `json:"page"`
-	Mode   *string `json:"mode"`
-	Lang   *string `json:"lang"`
+	UserID  string  `json:"userID"`
+	Page    *int    `json:"page"`
+	Mode    *string `json:"mode"`
+	Lang    *string `json:"lang"`
+	Persist bool    `json:"persist"`
 }
 
 func postFollowLatestIllustsHandler(ctx *fiber.Ctx) error {
@@ -557,6 +629,24 @@ func postFollowLatestIllustsHandler(ctx *fiber.Ctx) error {
 
 	}
 
+	// persist 时在后台任务里入库这一页的作品，响应里带上任务以便查询进度
+	if payload.Persist && pixivData != nil {
+		pids, _, parseErr := getPidsFromFollowLatest(pixivData)
+		if parseErr != nil {
+			log.Error().Err(parseErr).Str("userID", userID).Int("page", page).Msg("Handler: Failed to persist follow_latest illusts")
+			return sendCommonResponse(ctx, fiber.StatusInternalServerError, "保存关注动态作品失败", nil)
+		}
+		job, _ := startJob("follow-latest-ingest", fmt.Sprintf("%s:%d", userID, page), func(job *backgroundJob) error {
+			added, _, err := ingestFollowLatestPids(pids, job)
+			if err != nil {
+				return err
+			}
+			job.setMessage(fmt.Sprintf("新增 %d 个作品", added))
+			return nil
+		})
+		pixivData["job"] = job.snapshot()
+	}
+
 	log.Info().Str("userID", userID).Int("page", page).Str("mode", mode).Msg("Handler: Successfully processed follow_latest request, sending response")
 	return sendCommonResponse(ctx, fiber.StatusOK, "成功获取关注用户的最新插画 (Successfully retrieved latest illustrations from followed users)", pixivData)
 }
@@ -621,140 +711,75 @@ func fetchIllustRecommendInit(illustID string, limit int, lang string, userID st
 	return pixivData, nil
 }
 
-func updateAllPixivImages(concurrencyLimit int) error {
-	log.Info().Msg("Starting update process for all Pixiv images")
-
-	pids, err := database.GetAllPids()
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get all PIDs from database") // Structured error logging
-		return fmt.Errorf("failed to get pids to update: %w", err)
+// applyRefreshPolicyDefaults 为未填写的参数设置默认值
+func applyRefreshPolicyDefaults(policy *structs.RefreshPolicy) {
+	if policy.Policy == "" {
+		policy.Policy = structs.RefreshPolicyAll
 	}
-
-	if len(pids) == 0 {
-		log.Info().Msg("No PIDs found in the database. Nothing to update.")
-		return nil
-	}
-
-	log.Info().
-		Int("pid_count", len(pids)).
-		Int("concurrency_limit", concurrencyLimit).
-		Msg("Found PIDs to process. Starting concurrent updates")
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, concurrencyLimit)
-	errorChan := make(chan error, len(pids))
-	var errorCount int32 = 0
-	var processedCount int32 = 0
-
-	startTime := time.Now()
-	for _, pid := range pids {
-		wg.Add(1)
-		sem <- struct{}{}
-
-		go func(currentPid int) {
-			defer wg.Done()
-			defer func() { <-sem }()
-
-			log.Debug().Int("pid", currentPid).Msg("Processing PID")
-			_, err := fetchPixivIllustDataFromPixiv(strconv.Itoa(currentPid), "http://localhost:7890")
-
-			atomic.AddInt32(&processedCount, 1)
-			currentProcessed := atomic.LoadInt32(&processedCount)
-
-			if err != nil {
-				log.Error().Err(err).Int("pid", currentPid).Msg("Error processing PID")
-				errorChan <- fmt.Errorf("PID %d: %w", currentPid, err)
-				atomic.AddInt32(&errorCount, 1)
-			} else {
-				log.Info().
-					Int("pid", currentPid).
-					Int32("processed_count", currentProcessed).
-					Int("total_pids", len(pids)).
-					Msg("Successfully processed PID")
-			}
-		}(pid)
+	if policy.Days <= 0 {
+		policy.Days = 30
 	}
-
-	log.Info().Msg("All processing jobs launched. Waiting for completion")
-	wg.Wait()
-	close(errorChan)
-	log.Info().Msg("All processing jobs finished")
-
-	var errorsCollected []error
-	for err := range errorChan {
-		errorsCollected = append(errorsCollected, err)
+	if policy.RecentCount <= 0 {
+		policy.RecentCount = 200
 	}
-
-	duration := time.Since(startTime)
-	finalProcessedCount := atomic.LoadInt32(&processedCount)
-	finalErrorCount := atomic.LoadInt32(&errorCount)
-
-	log.Info().
-		Dur("duration", duration).
-		Int32("processed_count", finalProcessedCount).
-		Int32("error_count", finalErrorCount).
-		Msg("Update process finished")
-
-	if len(errorsCollected) > 0 {
-		log.Warn().Msg("Update process completed with errors")
-		errorSummary := fmt.Sprintf("encountered %d errors during update:", len(errorsCollected))
-		for i, e := range errorsCollected {
-			if i < 10 {
-				errorSummary += fmt.Sprintf("\n - %v", e)
-			} else if i == 10 {
-				errorSummary += "\n - ... (more errors logged above)"
-				break
-			}
-		}
-		return errors.New(errorSummary)
+	if policy.RecentDays <= 0 {
+		policy.RecentDays = 1
 	}
-
-	log.Info().Msg("Successfully processed all PIDs without errors.")
-	return nil
 }
 
-func triggerUpdateAllHandler(c *fiber.Ctx) error {
-	limit := 1
-
-	log.Info().Int("concurrency_limit", limit).Msg("Received request to trigger Pixiv update process")
-	go func(requestedLimit int) {
-		log.Info().Int("concurrency_limit", requestedLimit).Msg("Starting background Pixiv update task...")
-		err := updateAllPixivImages(requestedLimit)
-		if err != nil {
-			log.Error().Err(err).Msg("Background Pixiv update task finished with error")
-		} else {
-			log.Info().Msg("Background Pixiv update task finished successfully")
+// imageUpdateJobKey 由策略和它实际用到的参数组成，参数不同的同类刷新不会被当成同一个任务
+func imageUpdateJobKey(policy structs.RefreshPolicy) string {
+	key := policy.Policy
+	switch policy.Policy {
+	case structs.RefreshPolicyStale:
+		key += fmt.Sprintf(" days=%d", policy.Days)
+	case structs.RefreshPolicyTiered:
+		key += fmt.Sprintf(" days=%d recent=%d/%d", policy.Days, policy.RecentCount, policy.RecentDays)
+	case structs.RefreshPolicySearch:
+		if policy.Search != nil {
+			// 分页和排序不影响选出的作品
+			search := *policy.Search
+			search.Page, search.PageSize, search.SortBy, search.SortOrder = 0, 0, "", ""
+			filter, _ := jsoniter.MarshalToString(search)
+			key += " " + filter
 		}
-	}(limit)
-
-	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
-		"message":           "Pixiv image update process initiated.",
-		"concurrency_limit": limit,
-		"status_check_info": "Check server logs for progress and completion status.",
-		"timestamp":         time.Now(),
-	})
+	}
+	if policy.Limit > 0 {
+		key += fmt.Sprintf(" limit=%d", policy.Limit)
+	}
+	return key
 }
 
-func updateAllPixivImagesChecker(concurrencyLimit int) error {
-	// 该函数仅用于再次查询bookmark为0的画作重新获取，避免因网络问题没获取到图片
+// maxImageUpdateConcurrency 限制同时请求 Pixiv 的数量，避免触发限流
+const maxImageUpdateConcurrency = 4
 
-	log.Info().Msg("Starting update process for all Pixiv images")
+func updatePixivImages(job *backgroundJob, policy structs.RefreshPolicy, concurrencyLimit int) error {
+	applyRefreshPolicyDefaults(&policy)
+	if concurrencyLimit < 1 {
+		concurrencyLimit = 1
+	}
+	if concurrencyLimit > maxImageUpdateConcurrency {
+		concurrencyLimit = maxImageUpdateConcurrency
+	}
+	log.Info().Str("policy", policy.Policy).Msg("Starting update process for Pixiv images")
 
-	pids, err := database.GetPidsByBookmarkRange(0, 0)
+	pids, err := database.GetPidsForRefresh(policy, time.Now())
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get all PIDs from database") // Structured error logging
+		log.Error().Err(err).Str("policy", policy.Policy).Msg("Failed to get PIDs from database") // Structured error logging
 		return fmt.Errorf("failed to get pids to update: %w", err)
 	}
 
 	if len(pids) == 0 {
-		log.Info().Msg("No PIDs found in the database. Nothing to update.")
+		log.Info().Str("policy", policy.Policy).Msg("No PIDs selected by refresh policy. Nothing to update.")
 		return nil
 	}
 
 	log.Info().
+		Str("policy", policy.Policy).
 		Int("pid_count", len(pids)).
 		Int("concurrency_limit", concurrencyLimit).
 		Msg("Found PIDs to process. Starting concurrent updates")
+	job.setTotal(len(pids))
 
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, concurrencyLimit)
@@ -773,6 +798,7 @@ func updateAllPixivImagesChecker(concurrencyLimit int) error {
 
 			log.Debug().Int("pid", currentPid).Msg("Processing PID")
 			_, err := fetchPixivIllustDataFromPixiv(strconv.Itoa(currentPid), "http://localhost:7890")
+			job.step(err)
 
 			atomic.AddInt32(&processedCount, 1)
 			currentProcessed := atomic.LoadInt32(&processedCount)
@@ -781,12 +807,18 @@ func updateAllPixivImagesChecker(concurrencyLimit int) error {
 				log.Error().Err(err).Int("pid", currentPid).Msg("Error processing PID")
 				errorChan <- fmt.Errorf("PID %d: %w", currentPid, err)
 				atomic.AddInt32(&errorCount, 1)
+				if recordErr := database.RecordRefreshFailure(currentPid, err.Error()); recordErr != nil {
+					log.Error().Err(recordErr).Int("pid", currentPid).Msg("Failed to record refresh failure")
+				}
 			} else {
 				log.Info().
 					Int("pid", currentPid).
 					Int32("processed_count", currentProcessed).
 					Int("total_pids", len(pids)).
 					Msg("Successfully processed PID")
+				if clearErr := database.ClearRefreshFailure(currentPid); clearErr != nil {
+					log.Error().Err(clearErr).Int("pid", currentPid).Msg("Failed to clear refresh failure")
+				}
 			}
 		}(pid)
 	}
@@ -829,24 +861,74 @@ func updateAllPixivImagesChecker(concurrencyLimit int) error {
 	return nil
 }
 
+func startPixivImageUpdate(c *fiber.Ctx, policy structs.RefreshPolicy, concurrencyLimit int) error {
+	applyRefreshPolicyDefaults(&policy)
+	log.Info().Str("policy", policy.Policy).Int("concurrency_limit", concurrencyLimit).Msg("Received request to trigger Pixiv update process")
+	job, started := imageUpdateJob(policy, concurrencyLimit).start()
+	return sendJobStartedResponse(c, job, started)
+}
+
+func imageUpdateJob(policy structs.RefreshPolicy, concurrencyLimit int) jobSpec {
+	applyRefreshPolicyDefaults(&policy)
+	return jobSpec{
+		jobType: "image-update",
+		key:     imageUpdateJobKey(policy),
+		run: func(job *backgroundJob) error {
+			return updatePixivImages(job, policy, concurrencyLimit)
+		},
+	}
+}
+
+// knownRefreshPolicies 为可以提交的刷新策略，空字符串等同于 all
+var knownRefreshPolicies = map[string]bool{
+	"":                           true,
+	structs.RefreshPolicyAll:     true,
+	structs.RefreshPolicyStale:   true,
+	structs.RefreshPolicyTiered:  true,
+	structs.RefreshPolicyFailed:  true,
+	structs.RefreshPolicyChecker: true,
+	structs.RefreshPolicySearch:  true,
+}
+
+// triggerUpdateAllHandler 通过查询参数选择刷新策略，默认刷新全部作品
+func triggerUpdateAllHandler(c *fiber.Ctx) error {
+	policy := structs.RefreshPolicy{
+		// Query 返回的字符串只在本次请求内有效，传给后台任务前需要复制
+		Policy:      strings.Clone(c.Query("policy", structs.RefreshPolicyAll)),
+		Days:        c.QueryInt("days"),
+		RecentCount: c.QueryInt("recent_count"),
+		RecentDays:  c.QueryInt("recent_days"),
+		Limit:       c.QueryInt("limit"),
+	}
+	if !knownRefreshPolicies[policy.Policy] {
+		return sendCommonResponse(c, fiber.StatusBadRequest, "未知的刷新策略: "+policy.Policy, nil)
+	}
+	if policy.Policy == structs.RefreshPolicySearch {
+		return sendCommonResponse(c, fiber.StatusBadRequest, "search 策略需要通过 POST /api/pixiv/image/refresh 提交搜索条件", nil)
+	}
+	return startPixivImageUpdate(c, policy, c.QueryInt("concurrency", 1))
+}
+
+// triggerUpdateAllHandlerChecker 仅用于再次查询bookmark为0的画作重新获取，避免因网络问题没获取到图片
 func triggerUpdateAllHandlerChecker(c *fiber.Ctx) error {
-	limit := 1
+	return startPixivImageUpdate(c, structs.RefreshPolicy{Policy: structs.RefreshPolicyChecker}, 1)
+}
 
-	log.Info().Int("concurrency_limit", limit).Msg("Received request to trigger Pixiv update process")
-	go func(requestedLimit int) {
-		log.Info().Int("concurrency_limit", requestedLimit).Msg("Starting background Pixiv update task...")
-		err := updateAllPixivImagesChecker(requestedLimit)
-		if err != nil {
-			log.Error().Err(err).Msg("Background Pixiv update task finished with error")
-		} else {
-			log.Info().Msg("Background Pixiv update task finished successfully")
-		}
-	}(limit)
+type RefreshRequestPayload struct {
+	structs.RefreshPolicy
+	Concurrency int `json:"concurrency"`
+}
 
-	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
-		"message":           "Pixiv image update process initiated.",
-		"concurrency_limit": limit,
-		"status_check_info": "Check server logs for progress and completion status.",
-		"timestamp":         time.Now(),
-	})
+func postRefreshHandler(c *fiber.Ctx) error {
+	var payload RefreshRequestPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return sendCommonResponse(c, fiber.StatusBadRequest, "无效的请求体 JSON 格式 (Invalid request body JSON format)", nil)
+	}
+	if !knownRefreshPolicies[payload.Policy] {
+		return sendCommonResponse(c, fiber.StatusBadRequest, "未知的刷新策略: "+payload.Policy, nil)
+	}
+	if payload.Policy == structs.RefreshPolicySearch && payload.Search == nil {
+		return sendCommonResponse(c, fiber.StatusBadRequest, "search 策略需要提供 search 条件", nil)
+	}
+	return startPixivImageUpdate(c, payload.RefreshPolicy, payload.Concurrency)
 }
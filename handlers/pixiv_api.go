@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const pixivProxy = "http://127.0.0.1:7890"
+
+var ErrPixivAPIError = errors.New("pixiv API returned an error")
+
+var ErrPixivUserIDUnknown = errors.New("cannot determine pixiv user id from cookie")
+
+// getPixivUserID 从 cookie 的 PHPSESSID（形如 12345678_xxxx）中取出当前登录用户的 id
+func getPixivUserID() (string, error) {
+	cookie, err := database.GetPixivCookie()
+	if err != nil {
+		return "", err
+	}
+	for _, part := range strings.Split(cookie, ";") {
+		name, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || name != "PHPSESSID" {
+			continue
+		}
+		userID, _, found := strings.Cut(value, "_")
+		if found && userID != "" {
+			return userID, nil
+		}
+	}
+	return "", ErrPixivUserIDUnknown
+}
+
+func newPixivClient() *http.Client {
+	proxyURL, _ := url.Parse(pixivProxy)
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyURL(proxyURL),
+		},
+		Timeout: 30 * time.Second,
+	}
+}
+
+// doPixivRequest 发送带 cookie 的请求并解析 Pixiv ajax 接口的 JSON，错误统一包装为 ErrPixiv* 系列
+func doPixivRequest(req *http.Request, referer string, userID string) (map[string]interface{}, error) {
+	err := setPixivHeaders(req, userID)
+	if err != nil {
+		log.Error().Err(err).Str("url", req.URL.String()).Msg("doPixivRequest: Failed to set common headers")
+		return nil, fmt.Errorf("%w: setting common headers: %w", ErrInternalSetupFailed, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	if userID != "" {
+		req.Header.Set("x-user-id", userID)
+	}
+
+	res, err := newPixivClient().Do(req)
+	if err != nil {
+		log.Error().Err(err).Str("url", req.URL.String()).Msg("doPixivRequest: Failed to execute request to Pixiv")
+		return nil, fmt.Errorf("%w: executing request: %w", ErrPixivRequestFailed, err)
+	}
+	defer res.Body.Close()
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Error().Err(err).Str("url", req.URL.String()).Msg("doPixivRequest: Failed to read response body")
+		return nil, fmt.Errorf("%w: reading body: %w", ErrPixivReadBodyFailed, err)
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrPixivNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		log.Error().Int("status", res.StatusCode).Str("body", string(bodyBytes)).Str("url", req.URL.String()).Msg("doPixivRequest: Pixiv returned non-OK status")
+		return nil, fmt.Errorf("%w: status code %d", ErrPixivBadStatus, res.StatusCode)
+	}
+
+	var pixivData map[string]interface{}
+	err = jsoniter.Unmarshal(bodyBytes, &pixivData)
+	if err != nil {
+		log.Error().Err(err).Str("body", string(bodyBytes)).Str("url", req.URL.String()).Msg("doPixivRequest: Failed to parse JSON")
+		return nil, fmt.Errorf("%w: unmarshaling json: %w", ErrPixivParseFailed, err)
+	}
+	if isError, _ := pixivData["error"].(bool); isError {
+		message, _ := pixivData["message"].(string)
+		return nil, fmt.Errorf("%w: %s", ErrPixivAPIError, message)
+	}
+	return pixivData, nil
+}
+
+func fetchPixivAjax(fullURL string, referer string, userID string) (map[string]interface{}, error) {
+	log.Debug().Str("url", fullURL).Str("userID", userID).Msg("fetchPixivAjax: Preparing request")
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		log.Error().Err(err).Str("url", fullURL).Msg("fetchPixivAjax: Failed to create request object")
+		return nil, fmt.Errorf("%w: creating request: %w", ErrInternalSetupFailed, err)
+	}
+	return doPixivRequest(req, referer, userID)
+}
+
+// getPixivBody 取出 ajax 响应中的 body 对象
+func getPixivBody(pixivData map[string]interface{}) (map[string]interface{}, error) {
+	body, ok := pixivData["body"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: field 'body' is missing or not an object", ErrPixivParseFailed)
+	}
+	return body, nil
+}
+
+func sendPixivErrorResponse(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, ErrInternalSetupFailed) {
+		return sendCommonResponse(ctx, fiber.StatusInternalServerError, "内部服务器设置错误 (Internal server setup error)", nil)
+	} else if errors.Is(err, ErrPixivCSRFTokenNotFound) {
+		return sendCommonResponse(ctx, fiber.StatusBadGateway, "无法获取 Pixiv CSRF token，请检查 cookie 是否有效", nil)
+	} else if errors.Is(err, ErrPixivUserIDUnknown) {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "无法从 cookie 中获取 Pixiv 用户 id，请检查 cookie 或指定 user_id", nil)
+	} else if errors.Is(err, ErrPixivRequestFailed) {
+		return sendCommonResponse(ctx, fiber.StatusServiceUnavailable, "无法连接到 Pixiv API (Could not connect to Pixiv API)", nil)
+	} else if errors.Is(err, ErrPixivNotFound) {
+		return sendCommonResponse(ctx, fiber.StatusNotFound, "Pixiv 资源未找到", nil)
+	} else if errors.Is(err, ErrPixivBadStatus) || errors.Is(err, ErrPixivAPIError) {
+		errMsg := fmt.Sprintf("Pixiv API 请求失败 (Pixiv API request failed): %v", err)
+		return sendCommonResponse(ctx, fiber.StatusBadGateway, errMsg, nil)
+	} else if errors.Is(err, ErrPixivReadBodyFailed) {
+		return sendCommonResponse(ctx, fiber.StatusInternalServerError, "读取 Pixiv 响应失败 (Failed to read Pixiv response)", nil)
+	} else if errors.Is(err, ErrPixivParseFailed) {
+		return sendCommonResponse(ctx, fiber.StatusInternalServerError, "解析 Pixiv 响应失败 (Failed to parse Pixiv response)", nil)
+	}
+	return sendCommonResponse(ctx, fiber.StatusInternalServerError, fmt.Sprintf("处理请求时发生错误: %s", err.Error()), nil)
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const bookmarkPageSize = 48
+
+type pixivBookmarkWork struct {
+	PID        int
+	BookmarkID string
+	Private    bool
+	Tags       []string
+}
+
+// fetchPixivBookmarkPage 获取一页收藏，rest 为 show（公开）或 hide（非公开）
+func fetchPixivBookmarkPage(userID string, rest string, offset int) ([]pixivBookmarkWork, int, error) {
+	params := url.Values{}
+	params.Add("tag", "")
+	params.Add("offset", strconv.Itoa(offset))
+	params.Add("limit", strconv.Itoa(bookmarkPageSize))
+	params.Add("rest", rest)
+	params.Add("lang", "zh")
+	fullURL := fmt.Sprintf("https://www.pixiv.net/ajax/user/%s/illusts/bookmarks?%s", userID, params.Encode())
+	pixivData, err := fetchPixivAjax(fullURL, fmt.Sprintf("https://www.pixiv.net/users/%s/bookmarks/artworks", userID), userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	body, err := getPixivBody(pixivData)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := 0
+	if totalValue, ok := body["total"].(float64); ok {
+		total = int(totalValue)
+	}
+	// bookmarkTags 以收藏 id 为键，值是自己给这次收藏加的标签
+	bookmarkTags, _ := body["bookmarkTags"].(map[string]interface{})
+	workList, _ := body["works"].([]interface{})
+	var works []pixivBookmarkWork
+	for _, workItem := range workList {
+		workMap, _ := workItem.(map[string]interface{})
+		pid, err := strconv.Atoi(fmt.Sprint(workMap["id"]))
+		if err != nil {
+			continue
+		}
+		work := pixivBookmarkWork{PID: pid, Private: rest == "hide"}
+		if bookmarkData, ok := workMap["bookmarkData"].(map[string]interface{}); ok {
+			work.BookmarkID, _ = bookmarkData["id"].(string)
+			if private, ok := bookmarkData["private"].(bool); ok {
+				work.Private = private
+			}
+		}
+		tagList, _ := bookmarkTags[work.BookmarkID].([]interface{})
+		for _, tag := range tagList {
+			if tagName, ok := tag.(string); ok {
+				work.Tags = append(work.Tags, tagName)
+			}
+		}
+		works = append(works, work)
+	}
+	return works, total, nil
+}
+
+// syncPixivBookmarks 遍历公开和非公开收藏，补全图库并记录收藏信息，最后取消已不在收藏夹中的作品的收藏标记
+func syncPixivBookmarks(job *backgroundJob, userID string) error {
+	seen := make(map[int]bool)
+	for _, rest := range []string{"show", "hide"} {
+		offset := 0
+		for {
+			works, total, err := fetchPixivBookmarkPage(userID, rest, offset)
+			if err != nil {
+				return fmt.Errorf("failed to fetch bookmarks (rest=%s, offset=%d): %w", rest, offset, err)
+			}
+			if offset == 0 {
+				job.addTotal(total)
+			}
+			for _, work := range works {
+				seen[work.PID] = true
+				err := ingestBookmarkWork(work)
+				if err != nil {
+					log.Error().Err(err).Int("pid", work.PID).Msg("同步收藏作品失败")
+				}
+				job.step(err)
+			}
+			offset += len(works)
+			if len(works) == 0 || offset >= total {
+				break
+			}
+		}
+	}
+
+	bookmarkedPids, err := database.GetBookmarkedPids()
+	if err != nil {
+		return err
+	}
+	removed := 0
+	for _, pid := range bookmarkedPids {
+		if seen[pid] {
+			continue
+		}
+		if err := database.UnmarkImageBookmarked(pid); err != nil {
+			return err
+		}
+		removed++
+	}
+	job.setMessage(fmt.Sprintf("收藏 %d 个，取消收藏 %d 个", len(seen), removed))
+	return nil
+}
+
+func ingestBookmarkWork(work pixivBookmarkWork) error {
+	exists, err := database.CheckPidExists(work.PID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err = fetchPixivIllustDataFromPixiv(strconv.Itoa(work.PID), "http://localhost:7890")
+		if err != nil {
+			return err
+		}
+	}
+	return database.MarkImageBookmarked(work.PID, work.BookmarkID, work.Private, work.Tags)
+}
+
+func triggerBookmarkSync(ctx *fiber.Ctx) error {
+	userID := strings.Clone(ctx.Query("user_id"))
+	if userID == "" {
+		var err error
+		userID, err = getPixivUserID()
+		if err != nil {
+			return sendPixivErrorResponse(ctx, err)
+		}
+	}
+	job, started := bookmarkSyncJob(userID).start()
+	return sendJobStartedResponse(ctx, job, started)
+}
+
+func bookmarkSyncJob(userID string) jobSpec {
+	return jobSpec{
+		jobType: "bookmark-sync",
+		key:     userID,
+		run: func(job *backgroundJob) error {
+			return syncPixivBookmarks(job, userID)
+		},
+	}
+}
+
+var ErrPixivCSRFTokenNotFound = errors.New("csrf token not found in pixiv page")
+
+var csrfTokenPattern = regexp.MustCompile(`\\?"token\\?":\\?"([0-9a-f]+)\\?"`)
+
+// csrfTokenCache 按 cookie 缓存 CSRF token，cookie 变化后重新获取
+var csrfTokenCache struct {
+	sync.Mutex
+	cookie string
+	token  string
+}
+
+func getPixivCSRFToken() (string, error) {
+	cookie, err := database.GetPixivCookie()
+	if err != nil {
+		return "", err
+	}
+	csrfTokenCache.Lock()
+	defer csrfTokenCache.Unlock()
+	if csrfTokenCache.cookie == cookie && csrfTokenCache.token != "" {
+		return csrfTokenCache.token, nil
+	}
+
+	req, err := http.NewRequest("GET", "https://www.pixiv.net/", nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: creating request: %w", ErrInternalSetupFailed, err)
+	}
+	if err = setPixivHeaders(req, ""); err != nil {
+		return "", fmt.Errorf("%w: setting common headers: %w", ErrInternalSetupFailed, err)
+	}
+	res, err := newPixivClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: executing request: %w", ErrPixivRequestFailed, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: status code %d", ErrPixivBadStatus, res.StatusCode)
+	}
+	page, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("%w: reading body: %w", ErrPixivReadBodyFailed, err)
+	}
+	match := csrfTokenPattern.FindSubmatch(page)
+	if match == nil {
+		return "", ErrPixivCSRFTokenNotFound
+	}
+	csrfTokenCache.cookie = cookie
+	csrfTokenCache.token = string(match[1])
+	return csrfTokenCache.token, nil
+}
+
+func invalidatePixivCSRFToken() {
+	csrfTokenCache.Lock()
+	defer csrfTokenCache.Unlock()
+	csrfTokenCache.token = ""
+}
+
+// postPixivWithCSRF 发送需要 CSRF token 的 POST 请求，token 失效被拒绝时重新获取并重试一次
+func postPixivWithCSRF(fullURL string, contentType string, body []byte, referer string) (map[string]interface{}, error) {
+	var pixivData map[string]interface{}
+	for attempt := 0; attempt < 2; attempt++ {
+		token, err := getPixivCSRFToken()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest("POST", fullURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: creating request: %w", ErrInternalSetupFailed, err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Origin", "https://www.pixiv.net")
+		req.Header.Set("x-csrf-token", token)
+		pixivData, err = doPixivRequest(req, referer, "")
+		if err == nil {
+			return pixivData, nil
+		}
+		if !errors.Is(err, ErrPixivBadStatus) || attempt > 0 {
+			return nil, err
+		}
+		log.Warn().Err(err).Str("url", fullURL).Msg("postPixivWithCSRF: 请求被拒绝，重新获取 CSRF token 后重试")
+		invalidatePixivCSRFToken()
+	}
+	return pixivData, nil
+}
+
+func addPixivBookmark(payload BookmarkPayload) (string, error) {
+	if payload.Tags == nil {
+		payload.Tags = []string{}
+	}
+	body, err := jsoniter.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: marshaling payload: %w", ErrInternalSetupFailed, err)
+	}
+	pixivData, err := postPixivWithCSRF("https://www.pixiv.net/ajax/illusts/bookmarks/add", "application/json; charset=utf-8",
+		body, fmt.Sprintf("https://www.pixiv.net/artworks/%s", payload.IllustID))
+	if err != nil {
+		return "", err
+	}
+	// 重复收藏时 body 为 null
+	bookmarkBody, _ := pixivData["body"].(map[string]interface{})
+	bookmarkId, _ := bookmarkBody["last_bookmark_id"].(string)
+	return bookmarkId, nil
+}
+
+func deletePixivBookmark(pid int, bookmarkId string) error {
+	form := url.Values{}
+	form.Add("bookmark_id", bookmarkId)
+	_, err := postPixivWithCSRF("https://www.pixiv.net/ajax/illusts/bookmarks/delete", "application/x-www-form-urlencoded; charset=utf-8",
+		[]byte(form.Encode()), fmt.Sprintf("https://www.pixiv.net/artworks/%d", pid))
+	return err
+}
+
+// fetchPixivBookmarkId 从作品详情中读取当前的收藏 id，未收藏时返回空字符串
+func fetchPixivBookmarkId(pid int) (string, error) {
+	pixivData, err := fetchPixivAjax(fmt.Sprintf("https://www.pixiv.net/ajax/illust/%d", pid), fmt.Sprintf("https://www.pixiv.net/artworks/%d", pid), "")
+	if err != nil {
+		return "", err
+	}
+	body, err := getPixivBody(pixivData)
+	if err != nil {
+		return "", err
+	}
+	bookmarkData, _ := body["bookmarkData"].(map[string]interface{})
+	bookmarkId, _ := bookmarkData["id"].(string)
+	return bookmarkId, nil
+}
+
+func postPixivBookmark(ctx *fiber.Ctx) error {
+	pid, err := strconv.Atoi(ctx.Params("pid"))
+	if err != nil {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的 pid", nil)
+	}
+	var payload BookmarkPayload
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&payload); err != nil {
+			return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的请求体 JSON 格式 (Invalid request body JSON format)", nil)
+		}
+	}
+	if payload.Restrict != 0 && payload.Restrict != 1 {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "restrict 只能是 0（公开）或 1（非公开）", nil)
+	}
+	payload.IllustID = strconv.Itoa(pid)
+
+	bookmarkId, err := addPixivBookmark(payload)
+	if err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("添加 Pixiv 收藏失败")
+		return sendPixivErrorResponse(ctx, err)
+	}
+	if bookmarkId == "" {
+		bookmarkId, err = fetchPixivBookmarkId(pid)
+		if err != nil {
+			log.Warn().Err(err).Int("pid", pid).Msg("获取收藏 id 失败")
+		}
+	}
+	exists, err := database.CheckPidExists(pid)
+	if err == nil && exists {
+		err = database.MarkImageBookmarked(pid, bookmarkId, payload.Restrict == 1, payload.Tags)
+	}
+	if err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("更新本地收藏状态失败")
+		return sendCommonResponse(ctx, fiber.StatusInternalServerError, "已在 Pixiv 收藏，但更新本地收藏状态失败", nil)
+	}
+	return sendCommonResponse(ctx, fiber.StatusOK, "收藏成功", map[string]interface{}{
+		"pid":         pid,
+		"bookmark_id": bookmarkId,
+	})
+}
+
+func deletePixivBookmarkHandler(ctx *fiber.Ctx) error {
+	pid, err := strconv.Atoi(ctx.Params("pid"))
+	if err != nil {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的 pid", nil)
+	}
+	bookmarkId, err := database.GetBookmarkId(pid)
+	if err != nil {
+		return sendCommonResponse(ctx, fiber.StatusInternalServerError, err.Error(), nil)
+	}
+	if bookmarkId == "" {
+		bookmarkId, err = fetchPixivBookmarkId(pid)
+		if err != nil {
+			log.Error().Err(err).Int("pid", pid).Msg("获取收藏 id 失败")
+			return sendPixivErrorResponse(ctx, err)
+		}
+	}
+	if bookmarkId != "" {
+		err = deletePixivBookmark(pid, bookmarkId)
+		if err != nil {
+			log.Error().Err(err).Int("pid", pid).Msg("取消 Pixiv 收藏失败")
+			return sendPixivErrorResponse(ctx, err)
+		}
+	}
+	if err = database.UnmarkImageBookmarked(pid); err != nil {
+		log.Error().Err(err).Int("pid", pid).Msg("更新本地收藏状态失败")
+		return sendCommonResponse(ctx, fiber.StatusInternalServerError, "已取消 Pixiv 收藏，但更新本地收藏状态失败", nil)
+	}
+	return sendCommonResponse(ctx, fiber.StatusOK, "取消收藏成功", map[string]interface{}{
+		"pid": pid,
+	})
+}
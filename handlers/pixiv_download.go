@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// fetchPixivIllustPageURLs 返回作品每一页原图的地址，下标即 page_id
+func fetchPixivIllustPageURLs(pid int) ([]string, error) {
+	fullURL := fmt.Sprintf("https://www.pixiv.net/ajax/illust/%d/pages?lang=zh", pid)
+	pixivData, err := fetchPixivAjax(fullURL, fmt.Sprintf("https://www.pixiv.net/artworks/%d", pid), "")
+	if err != nil {
+		return nil, err
+	}
+	pageList, ok := pixivData["body"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: field 'body' is not a page list", ErrPixivParseFailed)
+	}
+	var urls []string
+	for _, pageItem := range pageList {
+		pageMap, _ := pageItem.(map[string]interface{})
+		pageURLs, _ := pageMap["urls"].(map[string]interface{})
+		original, _ := pageURLs["original"].(string)
+		if original == "" {
+			return nil, fmt.Errorf("%w: page without original url for pid %d", ErrPixivParseFailed, pid)
+		}
+		urls = append(urls, original)
+	}
+	return urls, nil
+}
+
+// downloadPixivIllust 把作品的所有页下载到 dir，文件名为 {pid}_p{page}.{ext}，与扫描本地图库时的规则一致
+func downloadPixivIllust(pid int, dir string) error {
+	urls, err := fetchPixivIllustPageURLs(pid)
+	if err != nil {
+		return err
+	}
+	for pageId, pageURL := range urls {
+		fileName := fmt.Sprintf("%d_p%d%s", pid, pageId, path.Ext(pageURL))
+		err = downloadPixivFile(pageURL, filepath.Join(dir, fileName))
+		if err != nil {
+			return fmt.Errorf("failed to download page %d of pid %d: %w", pageId, pid, err)
+		}
+		_, err = database.InsertPageByPid(pid, pageId)
+		if err != nil {
+			return fmt.Errorf("failed to insert page %d of pid %d: %w", pageId, pid, err)
+		}
+	}
+	return database.SetImageLocal(pid, true)
+}
+
+func downloadPixivFile(fileURL string, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		log.Debug().Str("dest", dest).Msg("文件已存在，跳过下载")
+		return nil
+	}
+	req, err := http.NewRequest("GET", fileURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: creating request: %w", ErrInternalSetupFailed, err)
+	}
+	req.Header.Set("Referer", "https://www.pixiv.net/")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.0.0 Safari/537.36")
+
+	res, err := newPixivClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: executing request: %w", ErrPixivRequestFailed, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status code %d", ErrPixivBadStatus, res.StatusCode)
+	}
+
+	// 先写临时文件再改名，避免中断时留下不完整的图片
+	tmp := dest + ".part"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, res.Body)
+	closeErr := file.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if closeErr != nil {
+		os.Remove(tmp)
+		return closeErr
+	}
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	inboxSourceFollowLatest = "follow_latest"
+
+	followLatestLastSyncKey = "follow_latest_last_sync"
+)
+
+// getPidsFromFollowLatest 解析 follow_latest 响应中 body.page.ids 和 isLastPage
+func getPidsFromFollowLatest(pixivData map[string]interface{}) ([]int, bool, error) {
+	body, err := getPixivBody(pixivData)
+	if err != nil {
+		return nil, false, err
+	}
+	page, ok := body["page"].(map[string]interface{})
+	if !ok {
+		return nil, false, fmt.Errorf("%w: field 'body.page' is missing", ErrPixivParseFailed)
+	}
+	isLastPage, _ := page["isLastPage"].(bool)
+	idList, _ := page["ids"].([]interface{})
+	var pids []int
+	for _, id := range idList {
+		pid, err := strconv.Atoi(fmt.Sprint(id))
+		if err != nil {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+	return pids, isLastPage, nil
+}
+
+// ingestFollowLatestPids 入库图库中还没有的作品并放进收件箱，reachedKnown 表示遇到了已有的作品
+func ingestFollowLatestPids(pids []int, job *backgroundJob) (added int, reachedKnown bool, err error) {
+	existing, err := database.GetExistingPids(pids)
+	if err != nil {
+		return 0, false, err
+	}
+	job.addTotal(len(pids) - len(existing))
+	for _, pid := range pids {
+		if existing[pid] {
+			reachedKnown = true
+			continue
+		}
+		_, err := fetchPixivIllustDataFromPixiv(strconv.Itoa(pid), "http://localhost:7890")
+		if err == nil {
+			err = database.AddToInbox(pid, inboxSourceFollowLatest)
+		}
+		if err != nil {
+			log.Error().Err(err).Int("pid", pid).Msg("关注动态作品入库失败")
+		} else {
+			added++
+		}
+		job.step(err)
+	}
+	return added, reachedKnown, nil
+}
+
+// syncFollowLatest 从第一页开始遍历关注动态，直到遇到图库里已有的作品、最后一页或 maxPages
+func syncFollowLatest(job *backgroundJob, userID string, maxPages int) error {
+	added := 0
+	for page := 1; page <= maxPages; page++ {
+		pixivData, err := fetchFollowLatestIllustsFromPixiv(page, "all", "zh", userID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch follow_latest page %d: %w", page, err)
+		}
+		pids, isLastPage, err := getPidsFromFollowLatest(pixivData)
+		if err != nil {
+			return err
+		}
+		pageAdded, reachedKnown, err := ingestFollowLatestPids(pids, job)
+		if err != nil {
+			return err
+		}
+		added += pageAdded
+		if reachedKnown || isLastPage {
+			break
+		}
+	}
+	job.setMessage(fmt.Sprintf("新增 %d 个作品", added))
+	return database.SetConfigValue(followLatestLastSyncKey, strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+func triggerFollowLatestSync(ctx *fiber.Ctx) error {
+	userID := strings.Clone(ctx.Query("user_id"))
+	if userID == "" {
+		var err error
+		userID, err = getPixivUserID()
+		if err != nil {
+			return sendPixivErrorResponse(ctx, err)
+		}
+	}
+	maxPages := ctx.QueryInt("max_pages", 10)
+	job, started := followLatestSyncJob(userID, maxPages).start()
+	return sendJobStartedResponse(ctx, job, started)
+}
+
+func followLatestSyncJob(userID string, maxPages int) jobSpec {
+	return jobSpec{
+		jobType: "follow-latest-sync",
+		key:     userID,
+		run: func(job *backgroundJob) error {
+			return syncFollowLatest(job, userID, maxPages)
+		},
+	}
+}
+
+func getInbox(ctx *fiber.Ctx) error {
+	items, total, err := database.GetInbox(ctx.QueryBool("all", false), ctx.QueryInt("page", 1), ctx.QueryInt("size", 20))
+	if err != nil {
+		log.Error().Err(err).Msg("查询收件箱出现错误")
+		return sendCommonResponse(ctx, 500, "查询收件箱出现错误", nil)
+	}
+	lastSync, err := database.GetConfigValue(followLatestLastSyncKey)
+	if err != nil {
+		return sendCommonResponse(ctx, 500, err.Error(), nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"items":     items,
+		"total":     total,
+		"last_sync": lastSync,
+	})
+}
+
+// InboxSeenPayload 需要给出 pids，或者用 all 明确表示全部标记为已读
+type InboxSeenPayload struct {
+	Pids []int `json:"pids"`
+	All  bool  `json:"all"`
+}
+
+func postInboxSeen(ctx *fiber.Ctx) error {
+	var payload InboxSeenPayload
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&payload); err != nil {
+			return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的请求体 JSON 格式 (Invalid request body JSON format)", nil)
+		}
+	}
+	if len(payload.Pids) == 0 && !payload.All {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "需要提供 pids，或者设置 all 为 true 全部标记为已读", nil)
+	}
+	var affected int64
+	var err error
+	if payload.All {
+		affected, err = database.MarkAllInboxSeen()
+	} else {
+		affected, err = database.MarkInboxSeen(payload.Pids)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("标记收件箱已读出现错误")
+		return sendCommonResponse(ctx, 500, "标记已读出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"updated": affected,
+	})
+}
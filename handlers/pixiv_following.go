@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	followingPageSize = 24
+
+	followingLastSyncKey = "following_last_sync"
+)
+
+// syncPixivFollowing 遍历公开和非公开关注列表写入 author 表，最后把不在列表中的作者标记为取消关注。
+// 只要有一个关注用户保存失败就跳过取消关注这一步，避免把仍在关注的作者误标记
+func syncPixivFollowing(job *backgroundJob, userID string) error {
+	followed := make(map[int]bool)
+	failed := 0
+	for _, rest := range []string{"show", "hide"} {
+		offset := 0
+		for {
+			pixivData, err := fetchPixivFollowingFromPixiv(userID, offset, followingPageSize, rest)
+			if err != nil {
+				return fmt.Errorf("failed to fetch following list (rest=%s, offset=%d): %w", rest, offset, err)
+			}
+			body, err := getPixivBody(pixivData)
+			if err != nil {
+				return err
+			}
+			total := getIntFromPixivField(body["total"])
+			if offset == 0 {
+				job.addTotal(total)
+			}
+			users, _ := body["users"].([]interface{})
+			for _, userItem := range users {
+				user, _ := userItem.(map[string]interface{})
+				authorId, err := storeFollowedUser(user, rest == "hide")
+				if err != nil {
+					log.Error().Err(err).Interface("userId", user["userId"]).Msg("保存关注用户失败")
+					failed++
+				} else {
+					followed[authorId] = true
+				}
+				job.step(err)
+			}
+			offset += len(users)
+			if len(users) == 0 || offset >= total {
+				break
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to store %d followed users, skipping unfollow sweep", failed)
+	}
+
+	followedIds, err := database.GetFollowedAuthorIds()
+	if err != nil {
+		return err
+	}
+	unfollowed := 0
+	for _, id := range followedIds {
+		if followed[id] {
+			continue
+		}
+		if err := database.MarkAuthorUnfollowed(id); err != nil {
+			return err
+		}
+		unfollowed++
+	}
+	job.setMessage(fmt.Sprintf("关注 %d 人，取消关注 %d 人", len(followed), unfollowed))
+	return database.SetConfigValue(followingLastSyncKey, strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+func storeFollowedUser(user map[string]interface{}, private bool) (int, error) {
+	uid, _ := user["userId"].(string)
+	name, _ := user["userName"].(string)
+	author, err := database.GetOrCreateAuthor(structs.Author{Name: name, UID: uid})
+	if err != nil {
+		return 0, err
+	}
+	return author.ID, database.MarkAuthorFollowed(author.ID, private)
+}
+
+func triggerFollowingSync(ctx *fiber.Ctx) error {
+	userID := strings.Clone(ctx.Query("user_id"))
+	if userID == "" {
+		var err error
+		userID, err = getPixivUserID()
+		if err != nil {
+			return sendPixivErrorResponse(ctx, err)
+		}
+	}
+	job, started := startJob("following-sync", userID, func(job *backgroundJob) error {
+		return syncPixivFollowing(job, userID)
+	})
+	return sendJobStartedResponse(ctx, job, started)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func fetchIllustRecommendByIds(illustID string, ids []string, lang string, userID string) (map[string]interface{}, error) {
+	params := url.Values{}
+	for _, id := range ids {
+		params.Add("illust_ids[]", id)
+	}
+	params.Add("lang", lang)
+	fullURL := "https://www.pixiv.net/ajax/illust/recommend/illusts?" + params.Encode()
+	return fetchPixivAjax(fullURL, fmt.Sprintf("https://www.pixiv.net/artworks/%s", illustID), userID)
+}
+
+// parseRecommendations 解析推荐列表，跳过广告占位等没有 id 的条目
+func parseRecommendations(body map[string]interface{}) []structs.Recommendation {
+	illustList, _ := body["illusts"].([]interface{})
+	recommendations := []structs.Recommendation{}
+	for _, illustItem := range illustList {
+		illust, _ := illustItem.(map[string]interface{})
+		pid, err := strconv.Atoi(fmt.Sprint(illust["id"]))
+		if err != nil {
+			continue
+		}
+		recommendation := structs.Recommendation{PID: pid}
+		recommendation.Title, _ = illust["title"].(string)
+		recommendation.URL, _ = illust["url"].(string)
+		recommendation.UserID, _ = illust["userId"].(string)
+		recommendation.UserName, _ = illust["userName"].(string)
+		recommendation.IllustType = getIntFromPixivField(illust["illustType"])
+		recommendation.XRestrict = getIntFromPixivField(illust["xRestrict"])
+		recommendation.AIType = getIntFromPixivField(illust["aiType"])
+		recommendation.Width = getIntFromPixivField(illust["width"])
+		recommendation.Height = getIntFromPixivField(illust["height"])
+		recommendation.PageCount = getIntFromPixivField(illust["pageCount"])
+		tagList, _ := illust["tags"].([]interface{})
+		for _, tag := range tagList {
+			if tagName, ok := tag.(string); ok {
+				recommendation.Tags = append(recommendation.Tags, tagName)
+			}
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+	return recommendations
+}
+
+// getIntFromPixivField Pixiv 的数字字段有时是数字有时是字符串
+func getIntFromPixivField(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+func getStringListFromPixivField(value interface{}) []string {
+	list, _ := value.([]interface{})
+	result := []string{}
+	for _, item := range list {
+		result = append(result, fmt.Sprint(item))
+	}
+	return result
+}
+
+func markRecommendationsInLibrary(recommendations []structs.Recommendation) error {
+	pids := make([]int, len(recommendations))
+	for i, recommendation := range recommendations {
+		pids[i] = recommendation.PID
+	}
+	existing, err := database.GetExistingPids(pids)
+	if err != nil {
+		return err
+	}
+	for i := range recommendations {
+		recommendations[i].InLibrary = existing[recommendations[i].PID]
+	}
+	return nil
+}
+
+// getIllustRecommendations 不带 next_ids 时返回首批推荐，带 next_ids 时按 limit 取下一批，剩余的 id 作为新的 next_ids 返回
+func getIllustRecommendations(ctx *fiber.Ctx) error {
+	pidStr := ctx.Params("pid")
+	if _, err := strconv.Atoi(pidStr); err != nil {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的 pid", nil)
+	}
+	limit := ctx.QueryInt("limit", 18)
+	if limit <= 0 {
+		limit = 18
+	}
+	lang := ctx.Query("lang", "zh")
+	userID, _ := getPixivUserID()
+
+	var pixivData map[string]interface{}
+	var nextIds []string
+	var err error
+	if nextIdsParam := ctx.Query("next_ids"); nextIdsParam != "" {
+		ids := strings.Split(nextIdsParam, ",")
+		if len(ids) > limit {
+			nextIds = ids[limit:]
+			ids = ids[:limit]
+		}
+		pixivData, err = fetchIllustRecommendByIds(pidStr, ids, lang, userID)
+	} else {
+		pixivData, err = fetchIllustRecommendInit(pidStr, limit, lang, userID)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("pid", pidStr).Msg("获取推荐作品失败")
+		return sendPixivErrorResponse(ctx, err)
+	}
+	body, err := getPixivBody(pixivData)
+	if err != nil {
+		return sendPixivErrorResponse(ctx, err)
+	}
+	if nextIds == nil {
+		nextIds = getStringListFromPixivField(body["nextIds"])
+	}
+
+	recommendations := parseRecommendations(body)
+	if err := markRecommendationsInLibrary(recommendations); err != nil {
+		log.Error().Err(err).Str("pid", pidStr).Msg("查询推荐作品是否已入库失败")
+		return sendCommonResponse(ctx, fiber.StatusInternalServerError, err.Error(), nil)
+	}
+	return sendCommonResponse(ctx, fiber.StatusOK, "成功", map[string]interface{}{
+		"recommendations": recommendations,
+		"next_ids":        nextIds,
+	})
+}
+
+type IngestRequestPayload struct {
+	Pids []int `json:"pids"`
+}
+
+// postIngestIllusts 在后台把选中的推荐作品入库，返回任务，每个作品的失败原因记录在日志里
+func postIngestIllusts(ctx *fiber.Ctx) error {
+	var payload IngestRequestPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的请求体 JSON 格式 (Invalid request body JSON format)", nil)
+	}
+	if len(payload.Pids) == 0 {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "pids 不能为空", nil)
+	}
+	keys := make([]string, 0, len(payload.Pids))
+	for _, pid := range payload.Pids {
+		keys = append(keys, strconv.Itoa(pid))
+	}
+	pids := payload.Pids
+	job, started := startJob("illust-ingest", strings.Join(keys, ","), func(job *backgroundJob) error {
+		job.setTotal(len(pids))
+		failed := 0
+		for _, pid := range pids {
+			_, err := fetchPixivIllustDataFromPixiv(strconv.Itoa(pid), "http://localhost:7890")
+			if err != nil {
+				log.Error().Err(err).Int("pid", pid).Msg("推荐作品入库失败")
+				failed++
+			}
+			job.step(err)
+		}
+		job.setMessage(fmt.Sprintf("共 %d 个作品，失败 %d 个", len(pids), failed))
+		return nil
+	})
+	return sendJobStartedResponse(ctx, job, started)
+}
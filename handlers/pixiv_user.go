@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func fetchPixivUserProfile(uid string) (structs.Author, structs.AuthorProfile, error) {
+	fullURL := fmt.Sprintf("https://www.pixiv.net/ajax/user/%s?full=1&lang=zh", uid)
+	pixivData, err := fetchPixivAjax(fullURL, fmt.Sprintf("https://www.pixiv.net/users/%s", uid), "")
+	if err != nil {
+		return structs.Author{}, structs.AuthorProfile{}, err
+	}
+	body, err := getPixivBody(pixivData)
+	if err != nil {
+		return structs.Author{}, structs.AuthorProfile{}, err
+	}
+
+	author := structs.Author{UID: uid}
+	author.Name, _ = body["name"].(string)
+	var profile structs.AuthorProfile
+	profile.AvatarURL, _ = body["image"].(string)
+	profile.AvatarBigURL, _ = body["imageBig"].(string)
+	profile.Comment, _ = body["comment"].(string)
+	profile.Webpage, _ = body["webpage"].(string)
+	profile.IsFollowed, _ = body["isFollowed"].(bool)
+	profile.FollowedBack, _ = body["followedBack"].(bool)
+	profile.Social = getSocialFromPixivUser(body)
+	return author, profile, nil
+}
+
+// getSocialFromPixivUser 解析 social 字段，没有社交链接时 Pixiv 返回的是空数组而不是对象
+func getSocialFromPixivUser(body map[string]interface{}) map[string]string {
+	social := map[string]string{}
+	socialMap, ok := body["social"].(map[string]interface{})
+	if !ok {
+		return social
+	}
+	for name, value := range socialMap {
+		entry, _ := value.(map[string]interface{})
+		if link, ok := entry["url"].(string); ok && link != "" {
+			social[name] = link
+		}
+	}
+	return social
+}
+
+func refreshAuthorProfile(uid string) (structs.AuthorDetail, error) {
+	authorInfo, profile, err := fetchPixivUserProfile(uid)
+	if err != nil {
+		return structs.AuthorDetail{}, err
+	}
+	author, err := database.GetOrCreateAuthor(authorInfo)
+	if err != nil {
+		return structs.AuthorDetail{}, fmt.Errorf("error getting or creating author for uid %s: %w", uid, err)
+	}
+	err = database.UpdateAuthorProfile(author.ID, profile)
+	if err != nil {
+		return structs.AuthorDetail{}, err
+	}
+	return database.GetAuthorDetail(author.ID, 20)
+}
+
+func refreshPixivUserProfile(ctx *fiber.Ctx) error {
+	uid := ctx.Params("uid")
+	detail, err := refreshAuthorProfile(uid)
+	if err != nil {
+		log.Error().Err(err).Str("uid", uid).Msg("获取 Pixiv 用户资料失败")
+		return sendPixivErrorResponse(ctx, err)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"author": detail,
+	})
+}
+
+// refreshStaleAuthorProfiles 更新从未获取过或超过 maxAge 的作者资料
+func refreshStaleAuthorProfiles(job *backgroundJob, maxAge time.Duration) error {
+	uids, err := database.GetAuthorUIDsForProfileRefresh(time.Now().Add(-maxAge).Unix())
+	if err != nil {
+		return err
+	}
+	job.setTotal(len(uids))
+	for _, uid := range uids {
+		_, err := refreshAuthorProfile(uid)
+		if err != nil {
+			log.Error().Err(err).Str("uid", uid).Msg("更新作者资料失败")
+		}
+		job.step(err)
+	}
+	return nil
+}
+
+func triggerAuthorProfileRefresh(ctx *fiber.Ctx) error {
+	days := ctx.QueryInt("days", 7)
+	job, started := startJob("author-profile-refresh", "", func(job *backgroundJob) error {
+		return refreshStaleAuthorProfiles(job, time.Duration(days)*24*time.Hour)
+	})
+	return sendJobStartedResponse(ctx, job, started)
+}
+
+// parsePixivIdSet 解析 {"123": null, ...} 形式的 id 集合，为空时 Pixiv 返回 []
+func parsePixivIdSet(value interface{}) []int {
+	idMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ids := make([]int, 0, len(idMap))
+	for idStr := range idMap {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// fetchPixivUserWorkPids 返回作者所有插画和漫画的 pid，按 pid 从新到旧排列
+func fetchPixivUserWorkPids(uid string) ([]int, error) {
+	fullURL := fmt.Sprintf("https://www.pixiv.net/ajax/user/%s/profile/all?lang=zh", uid)
+	pixivData, err := fetchPixivAjax(fullURL, fmt.Sprintf("https://www.pixiv.net/users/%s", uid), "")
+	if err != nil {
+		return nil, err
+	}
+	body, err := getPixivBody(pixivData)
+	if err != nil {
+		return nil, err
+	}
+	pids := append(parsePixivIdSet(body["illusts"]), parsePixivIdSet(body["manga"])...)
+	sort.Sort(sort.Reverse(sort.IntSlice(pids)))
+	return pids, nil
+}
+
+type UserSyncPayload struct {
+	Download  bool `json:"download"`
+	GalleryID int  `json:"gallery_id"`
+}
+
+// syncPixivUserWorks 把作者的作品补全到图库，已有的 pid 不会重新获取；downloadDir 不为空时同时下载原图
+func syncPixivUserWorks(job *backgroundJob, uid string, downloadDir string) error {
+	pids, err := fetchPixivUserWorkPids(uid)
+	if err != nil {
+		return fmt.Errorf("failed to list works of user %s: %w", uid, err)
+	}
+	existing, err := database.GetExistingPids(pids)
+	if err != nil {
+		return err
+	}
+	job.setTotal(len(pids))
+
+	added := 0
+	for _, pid := range pids {
+		err := syncPixivUserWork(pid, existing[pid], downloadDir)
+		if err != nil {
+			log.Error().Err(err).Int("pid", pid).Str("uid", uid).Msg("同步作品失败")
+		} else if !existing[pid] {
+			added++
+		}
+		job.step(err)
+	}
+	job.setMessage(fmt.Sprintf("共 %d 个作品，新增 %d 个", len(pids), added))
+	return nil
+}
+
+func syncPixivUserWork(pid int, exists bool, downloadDir string) error {
+	if !exists {
+		_, err := fetchPixivIllustDataFromPixiv(strconv.Itoa(pid), "http://localhost:7890")
+		if err != nil {
+			return err
+		}
+	}
+	if downloadDir == "" {
+		return nil
+	}
+	image, err := database.GetImageById(pid)
+	if err != nil {
+		return err
+	}
+	if image.Local {
+		return nil
+	}
+	return downloadPixivIllust(pid, downloadDir)
+}
+
+func triggerPixivUserSync(ctx *fiber.Ctx) error {
+	uid := strings.Clone(ctx.Params("uid"))
+	if _, err := strconv.Atoi(uid); err != nil {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的用户 uid", nil)
+	}
+	var payload UserSyncPayload
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&payload); err != nil {
+			return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的请求体 JSON 格式 (Invalid request body JSON format)", nil)
+		}
+	}
+
+	downloadDir := ""
+	if payload.Download {
+		gallery, err := database.GetGalleryById(payload.GalleryID)
+		if err != nil {
+			return sendCommonResponse(ctx, fiber.StatusBadRequest, "下载需要指定有效的 gallery_id", nil)
+		}
+		downloadDir = gallery.Path
+	}
+
+	job, started := startJob("user-sync", uid, func(job *backgroundJob) error {
+		return syncPixivUserWorks(job, uid, downloadDir)
+	})
+	return sendJobStartedResponse(ctx, job, started)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"strconv"
+	"strings"
+)
+
+func sendSavedSearchError(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, database.ErrSavedSearchNotFound):
+		return sendCommonResponse(ctx, 404, "保存的搜索不存在", nil)
+	case errors.Is(err, database.ErrSavedSearchNameConflict):
+		return sendCommonResponse(ctx, 409, "保存的搜索名已存在", nil)
+	}
+	log.Error().Err(err).Msg(message)
+	return sendCommonResponse(ctx, 500, message, nil)
+}
+
+func sendSavedSearch(ctx *fiber.Ctx, id int) error {
+	savedSearch, err := database.GetSavedSearch(id)
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "查询保存的搜索出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"saved_search": savedSearch,
+	})
+}
+
+func getSavedSearches(ctx *fiber.Ctx) error {
+	savedSearches, err := database.GetSavedSearches(ctx.QueryBool("collection", false))
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "查询保存的搜索出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"saved_searches": savedSearches,
+		"total":          len(savedSearches),
+	})
+}
+
+func getSavedSearchById(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 id", nil)
+	}
+	return sendSavedSearch(ctx, id)
+}
+
+func createSavedSearch(ctx *fiber.Ctx) error {
+	var payload structs.SavedSearchPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if payload.Name == nil || strings.TrimSpace(*payload.Name) == "" {
+		return sendCommonResponse(ctx, 400, "name 不能为空", nil)
+	}
+	if payload.Request == nil {
+		return sendCommonResponse(ctx, 400, "request 不能为空", nil)
+	}
+	isCollection := payload.IsCollection != nil && *payload.IsCollection
+	id, err := database.CreateSavedSearch(strings.TrimSpace(*payload.Name), *payload.Request, isCollection)
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "保存搜索出现错误")
+	}
+	return sendSavedSearch(ctx, id)
+}
+
+func updateSavedSearch(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 id", nil)
+	}
+	var payload structs.SavedSearchPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if payload.Name != nil {
+		name := strings.TrimSpace(*payload.Name)
+		if name == "" {
+			return sendCommonResponse(ctx, 400, "name 不能为空", nil)
+		}
+		payload.Name = &name
+	}
+	if err := database.UpdateSavedSearch(id, payload); err != nil {
+		return sendSavedSearchError(ctx, err, "修改保存的搜索出现错误")
+	}
+	return sendSavedSearch(ctx, id)
+}
+
+func deleteSavedSearch(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 id", nil)
+	}
+	if err := database.DeleteSavedSearch(id); err != nil {
+		return sendSavedSearchError(ctx, err, "删除保存的搜索出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", nil)
+}
+
+// executeSavedSearch 按保存的条件搜索，page 和 size 查询参数覆盖保存的分页
+func executeSavedSearch(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 id", nil)
+	}
+	savedSearch, err := database.GetSavedSearch(id)
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "查询保存的搜索出现错误")
+	}
+	req := savedSearch.Request
+	req.Page = ctx.QueryInt("page", req.Page)
+	req.PageSize = ctx.QueryInt("size", req.PageSize)
+	normalizeSearchRequest(&req)
+
+	images, count, err := database.SearchImages(req)
+	if err != nil {
+		return sendSavedSearchError(ctx, err, "执行保存的搜索出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"saved_search": savedSearch,
+		"images":       images,
+		"total":        count,
+	})
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"go_/utils"
+	"strings"
+	"time"
+)
+
+// scheduledTaskRunners 是可以被定时执行的任务，键与 scheduled_task.name 对应。
+// 返回的 jobSpec 与手动触发同一工作时相同，手动和定时的任务不会同时运行，成功时间也记在同一个 key 下
+var scheduledTaskRunners = map[string]func() (jobSpec, error){
+	"update-all": func() (jobSpec, error) {
+		return imageUpdateJob(structs.RefreshPolicy{Policy: structs.RefreshPolicyAll}, 1), nil
+	},
+	"checker": func() (jobSpec, error) {
+		return imageUpdateJob(structs.RefreshPolicy{Policy: structs.RefreshPolicyChecker}, 1), nil
+	},
+	"refresh-tiered": func() (jobSpec, error) {
+		return imageUpdateJob(structs.RefreshPolicy{Policy: structs.RefreshPolicyTiered}, 1), nil
+	},
+	"follow-latest-sync": func() (jobSpec, error) {
+		userID, err := getPixivUserID()
+		if err != nil {
+			return jobSpec{}, err
+		}
+		return followLatestSyncJob(userID, 10), nil
+	},
+	"bookmark-sync": func() (jobSpec, error) {
+		userID, err := getPixivUserID()
+		if err != nil {
+			return jobSpec{}, err
+		}
+		return bookmarkSyncJob(userID), nil
+	},
+	"gallery-rescan": func() (jobSpec, error) {
+		return jobSpec{jobType: "gallery-rescan", key: "all", run: rescanAllGalleries}, nil
+	},
+}
+
+var errScheduledTaskNotImplemented = errors.New("scheduled task has no runner")
+
+// maxScheduledPreview 限制 preview 参数，避免一次请求里计算过多的运行时间
+const maxScheduledPreview = 50
+
+// StartScheduler 每分钟检查一次 scheduled_task，运行在这一分钟内到期且已启用的任务
+func StartScheduler() {
+	go func() {
+		last := time.Now().Truncate(time.Minute)
+		for {
+			next := last.Add(time.Minute)
+			time.Sleep(time.Until(next))
+			runDueTasks(last, next)
+			last = next
+		}
+	}()
+	log.Info().Msg("定时任务调度器已启动")
+}
+
+func runDueTasks(from time.Time, to time.Time) {
+	tasks, err := database.GetScheduledTasks()
+	if err != nil {
+		log.Error().Err(err).Msg("读取定时任务失败")
+		return
+	}
+	for _, task := range tasks {
+		if !task.Enabled {
+			continue
+		}
+		schedule, err := utils.ParseCron(task.Cron)
+		if err != nil {
+			log.Error().Err(err).Str("task", task.Name).Str("cron", task.Cron).Msg("定时任务的 cron 表达式无效")
+			continue
+		}
+		next := schedule.Next(from)
+		if next.IsZero() || next.After(to) {
+			continue
+		}
+		if _, _, err := runScheduledTask(task.Name); err != nil {
+			log.Error().Err(err).Str("task", task.Name).Msg("启动定时任务失败")
+		}
+	}
+}
+
+// runScheduledTask 以后台任务运行定时任务并记录结果，同一工作正在运行时（包括手动触发的）不会重复启动
+func runScheduledTask(name string) (*backgroundJob, bool, error) {
+	build, ok := scheduledTaskRunners[name]
+	if !ok {
+		return nil, false, errScheduledTaskNotImplemented
+	}
+	spec, err := build()
+	if err != nil {
+		now := time.Now().Unix()
+		if recordErr := database.RecordScheduledTaskResult(name, now, JobStatusFailed, "", err.Error()); recordErr != nil {
+			log.Error().Err(recordErr).Str("task", name).Msg("记录定时任务结果失败")
+		}
+		return nil, false, err
+	}
+	run := spec.run
+	spec.run = func(job *backgroundJob) error {
+		if err := database.RecordScheduledTaskStart(name, time.Now().Unix()); err != nil {
+			log.Error().Err(err).Str("task", name).Msg("记录定时任务开始时间失败")
+		}
+		err := run(job)
+		status, errMessage := JobStatusSucceeded, ""
+		if err != nil {
+			status, errMessage = JobStatusFailed, err.Error()
+		}
+		if recordErr := database.RecordScheduledTaskResult(name, time.Now().Unix(), status, job.snapshot().Message, errMessage); recordErr != nil {
+			log.Error().Err(recordErr).Str("task", name).Msg("记录定时任务结果失败")
+		}
+		return err
+	}
+	job, started := spec.start()
+	return job, started, nil
+}
+
+// withNextRuns 为启用的任务填充接下来 count 次运行时间
+func withNextRuns(task structs.ScheduledTask, count int) structs.ScheduledTask {
+	task.NextRuns = []int64{}
+	if !task.Enabled {
+		return task
+	}
+	schedule, err := utils.ParseCron(task.Cron)
+	if err != nil {
+		return task
+	}
+	next := time.Now()
+	for i := 0; i < count; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		task.NextRuns = append(task.NextRuns, next.Unix())
+	}
+	return task
+}
+
+func getScheduledTasks(ctx *fiber.Ctx) error {
+	tasks, err := database.GetScheduledTasks()
+	if err != nil {
+		log.Error().Err(err).Msg("读取定时任务失败")
+		return sendCommonResponse(ctx, 500, "读取定时任务失败", nil)
+	}
+	count := ctx.QueryInt("preview", 5)
+	if count > maxScheduledPreview {
+		count = maxScheduledPreview
+	}
+	for i := range tasks {
+		tasks[i] = withNextRuns(tasks[i], count)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"tasks": tasks,
+		"total": len(tasks),
+	})
+}
+
+type UpdateScheduledTaskPayload struct {
+	Cron    *string `json:"cron"`
+	Enabled *bool   `json:"enabled"`
+}
+
+func updateScheduledTask(ctx *fiber.Ctx) error {
+	name := ctx.Params("name")
+	var payload UpdateScheduledTaskPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的请求体 JSON 格式 (Invalid request body JSON format)", nil)
+	}
+	task, err := database.GetScheduledTask(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sendCommonResponse(ctx, 404, "定时任务不存在", nil)
+		}
+		return sendCommonResponse(ctx, 500, err.Error(), nil)
+	}
+	if payload.Cron != nil {
+		if _, err := utils.ParseCron(*payload.Cron); err != nil {
+			return sendCommonResponse(ctx, fiber.StatusBadRequest, "无效的 cron 表达式: "+err.Error(), nil)
+		}
+		task.Cron = *payload.Cron
+	}
+	if payload.Enabled != nil {
+		task.Enabled = *payload.Enabled
+	}
+	if err := database.UpdateScheduledTask(name, task.Cron, task.Enabled); err != nil {
+		log.Error().Err(err).Str("task", name).Msg("更新定时任务失败")
+		return sendCommonResponse(ctx, 500, "更新定时任务失败", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"task": withNextRuns(task, 5),
+	})
+}
+
+func runScheduledTaskNow(ctx *fiber.Ctx) error {
+	name := strings.Clone(ctx.Params("name"))
+	if _, err := database.GetScheduledTask(name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sendCommonResponse(ctx, 404, "定时任务不存在", nil)
+		}
+		return sendCommonResponse(ctx, 500, err.Error(), nil)
+	}
+	job, started, err := runScheduledTask(name)
+	if errors.Is(err, errScheduledTaskNotImplemented) {
+		return sendCommonResponse(ctx, 404, "定时任务没有对应的实现", nil)
+	}
+	if err != nil {
+		return sendPixivErrorResponse(ctx, err)
+	}
+	return sendJobStartedResponse(ctx, job, started)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// statsCacheTTL 内重复请求直接返回缓存，统计图库占用需要遍历目录，代价较高
+const statsCacheTTL = 5 * time.Minute
+
+var statsCache struct {
+	sync.Mutex
+	stats   structs.LibraryStats
+	builtAt time.Time
+	// building 不为 nil 时已有请求在计算，其他请求等它完成后共用结果，计算期间不持有锁
+	building *statsBuild
+}
+
+type statsBuild struct {
+	done  chan struct{}
+	stats structs.LibraryStats
+	err   error
+}
+
+// getLibraryStats 返回库的统计数据，refresh=true 时忽略缓存重新计算
+func getLibraryStats(ctx *fiber.Ctx) error {
+	stats, err := loadLibraryStats(ctx.QueryBool("refresh", false))
+	if err != nil {
+		log.Error().Err(err).Msg("统计库数据出现错误")
+		return sendCommonResponse(ctx, 500, "统计库数据出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"stats": stats,
+	})
+}
+
+// loadLibraryStats 缓存有效时直接返回，否则计算一次并写回缓存，同时到达的请求只计算一次
+func loadLibraryStats(refresh bool) (structs.LibraryStats, error) {
+	statsCache.Lock()
+	if !refresh && !statsCache.builtAt.IsZero() && time.Since(statsCache.builtAt) <= statsCacheTTL {
+		stats := statsCache.stats
+		statsCache.Unlock()
+		return stats, nil
+	}
+	if build := statsCache.building; build != nil {
+		statsCache.Unlock()
+		<-build.done
+		return build.stats, build.err
+	}
+	build := &statsBuild{done: make(chan struct{})}
+	statsCache.building = build
+	statsCache.Unlock()
+
+	build.stats, build.err = buildLibraryStats()
+
+	statsCache.Lock()
+	if build.err == nil {
+		statsCache.stats = build.stats
+		statsCache.builtAt = time.Now()
+	}
+	statsCache.building = nil
+	statsCache.Unlock()
+	close(build.done)
+	return build.stats, build.err
+}
+
+func buildLibraryStats() (structs.LibraryStats, error) {
+	stats, err := database.GetLibraryStats()
+	if err != nil {
+		return stats, err
+	}
+
+	galleries, err := database.GetAllGalleries()
+	if err != nil {
+		return stats, err
+	}
+	stats.Storage = []structs.GalleryStorage{}
+	for _, gallery := range galleries {
+		storage := measureGalleryStorage(gallery)
+		stats.StorageBytes += storage.Bytes
+		stats.Storage = append(stats.Storage, storage)
+	}
+
+	lastSuccess, err := database.GetConfigValuesByPrefix(jobLastSuccessPrefix)
+	if err != nil {
+		return stats, err
+	}
+	stats.LastSuccess = make(map[string]int64)
+	for jobType, value := range lastSuccess {
+		if finishedAt, err := strconv.ParseInt(value, 10, 64); err == nil {
+			stats.LastSuccess[jobType] = finishedAt
+		}
+	}
+
+	stats.ScheduledTasks, err = database.GetScheduledTasks()
+	if err != nil {
+		return stats, err
+	}
+	stats.GeneratedAt = time.Now().Unix()
+	return stats, nil
+}
+
+// measureGalleryStorage 统计图库目录下所有文件的数量和大小，无法读取的文件和目录跳过，第一个错误记录在 Error 中
+func measureGalleryStorage(gallery structs.LocalGallery) structs.GalleryStorage {
+	storage := structs.GalleryStorage{ID: gallery.ID, Path: gallery.Path}
+	skip := func(path string, err error) {
+		log.Warn().Err(err).Str("path", path).Msg("统计图库占用时跳过无法读取的路径")
+		if storage.Error == "" {
+			storage.Error = err.Error()
+		}
+	}
+	_ = filepath.WalkDir(gallery.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			skip(path, err)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			skip(path, err)
+			return nil
+		}
+		storage.Files++
+		storage.Bytes += info.Size()
+		return nil
+	})
+	return storage
+}
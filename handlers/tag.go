@@ -1,16 +1,22 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 	"go_/database"
+	"go_/structs"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 func getTagsByPid() {
 
 }
 func getTagsWithPagination(ctx *fiber.Ctx) error {
-	var req SearchRequest
+	var req structs.TagListRequest
 	if err := ctx.BodyParser(&req); err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "cannot parse JSON",
@@ -24,7 +30,7 @@ func getTagsWithPagination(ctx *fiber.Ctx) error {
 	if req.PageSize <= 0 {
 		req.PageSize = 20
 	}
-	tags, err := database.GetTags(req.Page, req.PageSize)
+	tags, err := database.GetTags(req.Page, req.PageSize, req.Query)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
@@ -35,13 +41,263 @@ func getTagsWithPagination(ctx *fiber.Ctx) error {
 }
 
 func getTagsWithCount(ctx *fiber.Ctx) error {
-	tags, err := database.GetTagCounts()
+	req, err := parseStatisticsRequest(ctx)
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	tags, total, err := database.GetTagCounts(req)
 	if err != nil {
 		log.Error().Msg(err.Error())
 		return sendCommonResponse(ctx, 500, "错误", nil)
 	}
 	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
 		"tags":  tags,
-		"total": len(tags),
+		"total": total,
+	})
+}
+
+// sendTagManageError 把 tag 管理的错误映射成响应
+func sendTagManageError(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, database.ErrTagNotFound):
+		return sendCommonResponse(ctx, 404, "tag 不存在", nil)
+	case errors.Is(err, database.ErrTagNameConflict):
+		return sendCommonResponse(ctx, 409, "名字已被其他 tag 或别名使用", nil)
+	case errors.Is(err, database.ErrTagInUse):
+		return sendCommonResponse(ctx, 409, "tag 仍被作品使用", nil)
+	case errors.Is(err, database.ErrTagImplicationCycle):
+		return sendCommonResponse(ctx, 409, "蕴含规则会形成环", nil)
+	}
+	log.Error().Err(err).Msg(message)
+	return sendCommonResponse(ctx, 500, message, nil)
+}
+
+func renameTag(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 tag id", nil)
+	}
+	var payload structs.TagRenamePayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		return sendCommonResponse(ctx, 400, "name 不能为空", nil)
+	}
+	if err := database.RenameTag(id, payload.Name); err != nil {
+		return sendTagManageError(ctx, err, "重命名 tag 出现错误")
+	}
+	markTagSuggestIndexDirty()
+	tag, err := database.GetTagById(id)
+	if err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"tag": tag,
+	})
+}
+
+func mergeTags(ctx *fiber.Ctx) error {
+	var payload structs.TagMergePayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if payload.TargetID <= 0 || len(payload.SourceIDs) == 0 {
+		return sendCommonResponse(ctx, 400, "需要 target_id 和 source_ids", nil)
+	}
+	if err := database.MergeTags(payload.TargetID, payload.SourceIDs); err != nil {
+		return sendTagManageError(ctx, err, "合并 tag 出现错误")
+	}
+	markTagSuggestIndexDirty()
+	aliases, err := database.GetTagAliases(payload.TargetID)
+	if err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 别名出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"target_id": payload.TargetID,
+		"aliases":   aliases,
+	})
+}
+
+func getTagAliases(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 tag id", nil)
+	}
+	if _, err := database.GetTagById(id); err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 出现错误")
+	}
+	aliases, err := database.GetTagAliases(id)
+	if err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 别名出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"aliases": aliases,
+	})
+}
+
+func addTagAlias(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 tag id", nil)
+	}
+	var payload structs.TagAliasPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	payload.Alias = strings.TrimSpace(payload.Alias)
+	if payload.Alias == "" {
+		return sendCommonResponse(ctx, 400, "alias 不能为空", nil)
+	}
+	if err := database.AddTagAlias(id, payload.Alias); err != nil {
+		return sendTagManageError(ctx, err, "添加 tag 别名出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", nil)
+}
+
+func deleteTagAlias(ctx *fiber.Ctx) error {
+	alias, err := url.PathUnescape(ctx.Params("alias"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的别名", nil)
+	}
+	deleted, err := database.DeleteTagAlias(alias)
+	if err != nil {
+		return sendTagManageError(ctx, err, "删除 tag 别名出现错误")
+	}
+	if !deleted {
+		return sendCommonResponse(ctx, 404, "别名不存在", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", nil)
+}
+
+func deleteTag(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的 tag id", nil)
+	}
+	if err := database.DeleteTag(id); err != nil {
+		return sendTagManageError(ctx, err, "删除 tag 出现错误")
+	}
+	markTagSuggestIndexDirty()
+	return sendCommonResponse(ctx, 200, "成功", nil)
+}
+
+func deleteUnusedTags(ctx *fiber.Ctx) error {
+	deleted, err := database.DeleteUnusedTags()
+	if err != nil {
+		return sendTagManageError(ctx, err, "删除未使用的 tag 出现错误")
+	}
+	markTagSuggestIndexDirty()
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"deleted": deleted,
+	})
+}
+
+func getTagImplications(ctx *fiber.Ctx) error {
+	implications, err := database.GetTagImplications()
+	if err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 蕴含规则出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"implications": implications,
+	})
+}
+
+// addTagImplication 按名字添加规则，名字可以是别名，不存在的 tag 会被创建
+func addTagImplication(ctx *fiber.Ctx) error {
+	var payload structs.TagImplicationPayload
+	if err := ctx.BodyParser(&payload); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	payload.Tag = strings.TrimSpace(payload.Tag)
+	payload.ImpliedTag = strings.TrimSpace(payload.ImpliedTag)
+	if payload.Tag == "" || payload.ImpliedTag == "" {
+		return sendCommonResponse(ctx, 400, "需要 tag 和 implied_tag", nil)
+	}
+	tagId, err := database.GetOrCreateTagIdByName(payload.Tag)
+	if err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 出现错误")
+	}
+	impliedTagId, err := database.GetOrCreateTagIdByName(payload.ImpliedTag)
+	if err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 出现错误")
+	}
+	id, err := database.AddTagImplication(tagId, impliedTagId)
+	if err != nil {
+		return sendTagManageError(ctx, err, "添加 tag 蕴含规则出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"id":             id,
+		"tag_id":         tagId,
+		"implied_tag_id": impliedTagId,
+	})
+}
+
+func deleteTagImplication(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return sendCommonResponse(ctx, 400, "无效的规则 id", nil)
+	}
+	deleted, err := database.DeleteTagImplication(id)
+	if err != nil {
+		return sendTagManageError(ctx, err, "删除 tag 蕴含规则出现错误")
+	}
+	if !deleted {
+		return sendCommonResponse(ctx, 404, "规则不存在", nil)
+	}
+	markTagSuggestIndexDirty()
+	return sendCommonResponse(ctx, 200, "成功", nil)
+}
+
+// triggerApplyTagImplications 对已有作品重新应用全部蕴含规则，新增规则后调用
+func triggerApplyTagImplications(ctx *fiber.Ctx) error {
+	job, started := startJob("tag-implication", "all", func(job *backgroundJob) error {
+		job.setTotal(1)
+		added, err := database.ApplyAllTagImplications()
+		job.step(err)
+		if err != nil {
+			return err
+		}
+		markTagSuggestIndexDirty()
+		job.setMessage(fmt.Sprintf("新增 %d 个作品 tag", added))
+		return nil
+	})
+	return sendJobStartedResponse(ctx, job, started)
+}
+
+func getTagTree(ctx *fiber.Ctx) error {
+	tree, err := database.GetTagTree(ctx.QueryInt("root", 0))
+	if err != nil {
+		return sendTagManageError(ctx, err, "查询 tag 树出现错误")
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"tree": tree,
+	})
+}
+
+// getRelatedTags 返回与指定 tag 或当前搜索条件共现的 tag，用于逐级筛选
+func getRelatedTags(ctx *fiber.Ctx) error {
+	var req structs.RelatedTagRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return sendCommonResponse(ctx, 400, "解析请求体失败", nil)
+	}
+	if tag := strings.TrimSpace(req.Tag); tag != "" {
+		req.Search.Tags = append(req.Search.Tags, tag)
+	}
+	if req.Limit <= 0 || req.Limit > 200 {
+		req.Limit = 30
+	}
+	if req.MinCount <= 0 {
+		req.MinCount = 1
+	}
+	baseCount, related, err := database.GetRelatedTags(req.Search, req.SortBy, req.MinCount, req.Limit)
+	if err != nil {
+		log.Error().Err(err).Msg("查询共现 tag 出现错误")
+		return sendCommonResponse(ctx, 500, "查询共现 tag 出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"base_count": baseCount,
+		"tags":       related,
 	})
 }
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"go_/database"
+	"go_/structs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tagSuggestRebuildInterval 限制批量入库期间索引的重建频率
+const tagSuggestRebuildInterval = 10 * time.Second
+
+type tagSuggestEntry struct {
+	tag           structs.TagSuggestion
+	name          string
+	translateName string
+}
+
+// tagSuggestIndex 是按作品数排好序的 tag 列表，tag 变化后标记为 dirty，下次查询时重建
+var tagSuggestIndex struct {
+	sync.Mutex
+	entries []tagSuggestEntry
+	dirty   bool
+	builtAt time.Time
+}
+
+func init() {
+	tagSuggestIndex.dirty = true
+}
+
+// markTagSuggestIndexDirty 在入库或修改 tag 后调用
+func markTagSuggestIndexDirty() {
+	tagSuggestIndex.Lock()
+	tagSuggestIndex.dirty = true
+	tagSuggestIndex.Unlock()
+}
+
+func getTagSuggestEntries() ([]tagSuggestEntry, error) {
+	tagSuggestIndex.Lock()
+	defer tagSuggestIndex.Unlock()
+
+	if !tagSuggestIndex.dirty || (tagSuggestIndex.entries != nil && time.Since(tagSuggestIndex.builtAt) < tagSuggestRebuildInterval) {
+		return tagSuggestIndex.entries, nil
+	}
+	suggestions, err := database.GetTagSuggestions()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]tagSuggestEntry, len(suggestions))
+	for i, suggestion := range suggestions {
+		entries[i] = tagSuggestEntry{
+			tag:           suggestion,
+			name:          strings.ToLower(suggestion.Name),
+			translateName: strings.ToLower(suggestion.TranslateName),
+		}
+	}
+	tagSuggestIndex.entries = entries
+	tagSuggestIndex.dirty = false
+	tagSuggestIndex.builtAt = time.Now()
+	log.Debug().Int("tags", len(entries)).Msg("重建 tag 自动补全索引")
+	return entries, nil
+}
+
+// suggestTags 先返回原名或翻译以 q 开头的 tag，再返回包含 q 的 tag，各自按作品数排序，不区分大小写
+func suggestTags(entries []tagSuggestEntry, q string, limit int) []structs.TagSuggestion {
+	q = strings.ToLower(q)
+	prefix := []structs.TagSuggestion{}
+	var substring []structs.TagSuggestion
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.name, q) || strings.HasPrefix(entry.translateName, q) {
+			prefix = append(prefix, entry.tag)
+			if len(prefix) == limit {
+				break
+			}
+		} else if len(substring) < limit && (strings.Contains(entry.name, q) || strings.Contains(entry.translateName, q)) {
+			substring = append(substring, entry.tag)
+		}
+	}
+	result := append(prefix, substring...)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+func getTagSuggestions(ctx *fiber.Ctx) error {
+	q := strings.TrimSpace(ctx.Query("q"))
+	if q == "" {
+		return sendCommonResponse(ctx, 400, "q 不能为空", nil)
+	}
+	limit := ctx.QueryInt("limit", 10)
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	entries, err := getTagSuggestEntries()
+	if err != nil {
+		log.Error().Err(err).Msg("构建 tag 自动补全索引出现错误")
+		return sendCommonResponse(ctx, 500, "查询 tag 出现错误", nil)
+	}
+	return sendCommonResponse(ctx, 200, "成功", map[string]interface{}{
+		"tags": suggestTags(entries, q, limit),
+	})
+}
@@ -10,5 +10,6 @@ func main() {
 	app := fiber.New()
 	database.InitDatabase()
 	handlers.InitHandlers(app)
+	handlers.StartScheduler()
 	app.Listen(":23333")
 }
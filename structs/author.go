@@ -7,8 +7,48 @@ type Author struct {
 }
 
 type AuthorCount struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	UID   string `json:"uid"`
-	Count int    `json:"count"`
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	UID           string `json:"uid"`
+	Count         int    `json:"count"`
+	BookmarkTotal int    `json:"bookmark_total"`
+}
+
+type AuthorName struct {
+	Name        string `json:"name"`
+	FirstSeenAt int64  `json:"first_seen_at"`
+	LastSeenAt  int64  `json:"last_seen_at"`
+}
+
+type AuthorBookmarkStats struct {
+	Total      int     `json:"total"`
+	Average    float64 `json:"average"`
+	Max        int     `json:"max"`
+	Min        int     `json:"min"`
+	Bookmarked int     `json:"bookmarked"`
+}
+
+type AuthorDetail struct {
+	Author
+	Names         []AuthorName        `json:"names"`
+	ImageCount    int                 `json:"image_count"`
+	Tags          []TagCount          `json:"tags"`
+	BookmarkStats AuthorBookmarkStats `json:"bookmark_stats"`
+	// FirstSeenAt / LastSeenAt 取名字历史、作品上传时间和更新时间中最早和最晚的一个
+	FirstSeenAt int64         `json:"first_seen_at"`
+	LastSeenAt  int64         `json:"last_seen_at"`
+	Profile     AuthorProfile `json:"profile"`
+}
+
+type AuthorProfile struct {
+	AvatarURL        string            `json:"avatar_url"`
+	AvatarBigURL     string            `json:"avatar_big_url"`
+	Comment          string            `json:"comment"`
+	Webpage          string            `json:"webpage"`
+	Social           map[string]string `json:"social"`
+	IsFollowed       bool              `json:"is_followed"`
+	FollowedBack     bool              `json:"followed_back"`
+	FollowPrivate    bool              `json:"follow_private"`
+	FollowedAt       int64             `json:"followed_at"`
+	ProfileUpdatedAt int64             `json:"profile_updated_at"`
 }
@@ -0,0 +1,13 @@
+package structs
+
+type BookmarkSnapshot struct {
+	BookmarkCount int   `json:"bookmark_count"`
+	CapturedAt    int64 `json:"captured_at"`
+}
+
+type TrendingImage struct {
+	Image    Image `json:"image"`
+	Baseline int   `json:"baseline"`
+	Latest   int   `json:"latest"`
+	Growth   int   `json:"growth"`
+}
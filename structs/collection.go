@@ -0,0 +1,28 @@
+package structs
+
+type Collection struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// CoverPID 为实际使用的封面，没有手动设置时为集合里的第一张作品，空集合为 0
+	CoverPID   int       `json:"cover_pid"`
+	CoverURLs  ImageURLs `json:"cover_urls"`
+	ImageCount int       `json:"image_count"`
+	CreatedAt  int64     `json:"created_at"`
+	UpdatedAt  int64     `json:"updated_at"`
+	// Dynamic 为真时集合来自保存的搜索，ID 为 0，成员在读取时按 SavedSearchID 对应的搜索条件计算，
+	// 通过 /api/collection/dynamic/:saved_search_id 访问
+	Dynamic       bool `json:"dynamic"`
+	SavedSearchID int  `json:"saved_search_id,omitempty"`
+}
+
+// CollectionPayload 用于创建和修改，修改时为 nil 的字段保持不变，CoverPID 为 0 表示取消手动封面
+type CollectionPayload struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	CoverPID    *int    `json:"cover_pid"`
+}
+
+type CollectionImagesPayload struct {
+	PIDs []int `json:"pids"`
+}
@@ -0,0 +1,31 @@
+package structs
+
+// IllustMeta 是 Pixiv 作品详情里除标题、作者、收藏数和链接以外的元数据，时间为 unix 秒
+type IllustMeta struct {
+	Description  string `json:"description"`
+	CreateDate   int64  `json:"create_date"`
+	UploadDate   int64  `json:"upload_date"`
+	IllustType   int    `json:"illust_type"`
+	XRestrict    int    `json:"x_restrict"`
+	AIType       int    `json:"ai_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	PageCount    int    `json:"page_count"`
+	ViewCount    int    `json:"view_count"`
+	LikeCount    int    `json:"like_count"`
+	CommentCount int    `json:"comment_count"`
+	SeriesID     int    `json:"series_id"`
+	SeriesTitle  string `json:"series_title"`
+}
+
+const (
+	IllustTypeIllust = 0
+	IllustTypeManga  = 1
+	IllustTypeUgoira = 2
+)
+
+const (
+	AITypeUnknown   = 0
+	AITypeNotAI     = 1
+	AITypeGenerated = 2
+)
@@ -11,4 +11,6 @@ type Image struct {
 	URLs          ImageURLs `json:"urls"`
 	Tags          []Tag     `json:"tags"`
 	Pages         []Page    `json:"pages"`
+	BookmarkTags  []string  `json:"bookmark_tags"`
+	IllustMeta
 }
@@ -0,0 +1,8 @@
+package structs
+
+type InboxItem struct {
+	Image   Image  `json:"image"`
+	Source  string `json:"source"`
+	AddedAt int64  `json:"added_at"`
+	Seen    bool   `json:"seen"`
+}
@@ -0,0 +1,15 @@
+package structs
+
+type Job struct {
+	ID         int    `json:"id"`
+	Type       string `json:"type"`
+	Key        string `json:"key"`
+	Status     string `json:"status"`
+	Total      int    `json:"total"`
+	Processed  int    `json:"processed"`
+	Failed     int    `json:"failed"`
+	Message    string `json:"message"`
+	Error      string `json:"error"`
+	StartedAt  int64  `json:"started_at"`
+	FinishedAt int64  `json:"finished_at"`
+}
@@ -0,0 +1,17 @@
+package structs
+
+type Recommendation struct {
+	PID        int      `json:"pid"`
+	Title      string   `json:"title"`
+	IllustType int      `json:"illust_type"`
+	XRestrict  int      `json:"x_restrict"`
+	AIType     int      `json:"ai_type"`
+	URL        string   `json:"url"`
+	Tags       []string `json:"tags"`
+	UserID     string   `json:"user_id"`
+	UserName   string   `json:"user_name"`
+	Width      int      `json:"width"`
+	Height     int      `json:"height"`
+	PageCount  int      `json:"page_count"`
+	InLibrary  bool     `json:"in_library"`
+}
@@ -0,0 +1,24 @@
+package structs
+
+const (
+	RefreshPolicyAll     = "all"
+	RefreshPolicyStale   = "stale"
+	RefreshPolicyTiered  = "tiered"
+	RefreshPolicyFailed  = "failed"
+	RefreshPolicyChecker = "checker"
+	RefreshPolicySearch  = "search"
+)
+
+// RefreshPolicy 决定一次更新任务要重新获取哪些作品
+type RefreshPolicy struct {
+	Policy string `json:"policy"`
+	// stale：updated_at 早于 Days 天；tiered：其余作品的阈值
+	Days int `json:"days"`
+	// tiered：pid 最新的 RecentCount 个作品超过 RecentDays 天就刷新
+	RecentCount int `json:"recent_count"`
+	RecentDays  int `json:"recent_days"`
+	// search：按搜索条件选择，分页和排序字段会被忽略
+	Search *SearchRequest `json:"search,omitempty"`
+	// 每次最多刷新的数量，0 表示不限制，优先刷新最久未更新的
+	Limit int `json:"limit"`
+}
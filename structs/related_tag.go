@@ -0,0 +1,22 @@
+package structs
+
+// RelatedTagRequest 以 Search 匹配的作品为基准统计共现 tag，Tag 不为空时追加到 Search.Tags
+type RelatedTagRequest struct {
+	Tag      string        `json:"tag"`
+	Search   SearchRequest `json:"search"`
+	SortBy   string        `json:"sort_by"`
+	MinCount int           `json:"min_count"`
+	Limit    int           `json:"limit"`
+}
+
+// RelatedTag 的 Support 为基准作品中带该 tag 的比例，Lift 为 Support 与该 tag 在全部作品中比例的比值，
+// 大于 1 表示比平时更常一起出现
+type RelatedTag struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	TranslateName string  `json:"translate_name"`
+	Count         int     `json:"count"`
+	GlobalCount   int     `json:"global_count"`
+	Support       float64 `json:"support"`
+	Lift          float64 `json:"lift"`
+}
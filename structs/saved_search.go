@@ -0,0 +1,17 @@
+package structs
+
+type SavedSearch struct {
+	ID           int           `json:"id"`
+	Name         string        `json:"name"`
+	Request      SearchRequest `json:"request"`
+	IsCollection bool          `json:"is_collection"`
+	CreatedAt    int64         `json:"created_at"`
+	UpdatedAt    int64         `json:"updated_at"`
+}
+
+// SavedSearchPayload 用于创建和修改，修改时为 nil 的字段保持不变
+type SavedSearchPayload struct {
+	Name         *string        `json:"name"`
+	Request      *SearchRequest `json:"request"`
+	IsCollection *bool          `json:"is_collection"`
+}
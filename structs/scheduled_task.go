@@ -0,0 +1,14 @@
+package structs
+
+type ScheduledTask struct {
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	Cron           string  `json:"cron"`
+	Enabled        bool    `json:"enabled"`
+	LastRunAt      int64   `json:"last_run_at"`
+	LastFinishedAt int64   `json:"last_finished_at"`
+	LastStatus     string  `json:"last_status"`
+	LastMessage    string  `json:"last_message"`
+	LastError      string  `json:"last_error"`
+	NextRuns       []int64 `json:"next_runs"`
+}
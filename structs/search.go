@@ -0,0 +1,37 @@
+package structs
+
+type SearchRequest struct {
+	Tags             []string `json:"tags"`
+	Page             int      `json:"page"`
+	PageSize         int      `json:"size"`
+	SortBy           string   `json:"sort_by"`
+	SortOrder        string   `json:"sort_order"`
+	Author           string   `json:"author"`
+	MinBookmarkCount *int     `json:"min_bookmark_count,omitempty"`
+	MaxBookmarkCount *int     `json:"max_bookmark_count,omitempty"`
+	IsBookmarked     *bool    `json:"is_bookmarked,omitempty"`
+	FollowedAuthor   *bool    `json:"followed_author,omitempty"`
+	MinViewCount     *int     `json:"min_view_count,omitempty"`
+	MinLikeCount     *int     `json:"min_like_count,omitempty"`
+	MinCommentCount  *int     `json:"min_comment_count,omitempty"`
+	SeriesID         *int     `json:"series_id,omitempty"`
+	// 上传时间范围，unix 秒
+	UploadDateFrom *int64 `json:"upload_date_from,omitempty"`
+	UploadDateTo   *int64 `json:"upload_date_to,omitempty"`
+	MinWidth       *int   `json:"min_width,omitempty"`
+	MaxWidth       *int   `json:"max_width,omitempty"`
+	MinHeight      *int   `json:"min_height,omitempty"`
+	MaxHeight      *int   `json:"max_height,omitempty"`
+	// 宽高比为 width / height，大于 1 为横图
+	MinAspectRatio *float64 `json:"min_aspect_ratio,omitempty"`
+	MaxAspectRatio *float64 `json:"max_aspect_ratio,omitempty"`
+	// true 只要多页作品，false 只要单页作品
+	MultiPage   *bool `json:"multi_page,omitempty"`
+	IllustTypes []int `json:"illust_types,omitempty"`
+	ExcludeAI   bool  `json:"exclude_ai,omitempty"`
+	// XRestrict 指定允许的分级，MaxXRestrict 为分级上限，0 即只要全年龄；还没有抓到分级的旧作品不会命中
+	XRestrict    []int `json:"x_restrict,omitempty"`
+	MaxXRestrict *int  `json:"max_x_restrict,omitempty"`
+	// CollectionID 只搜索集合里的作品，此时可以用 sort_by=position 按集合内顺序排序
+	CollectionID *int `json:"collection_id,omitempty"`
+}
@@ -0,0 +1,11 @@
+package structs
+
+// StatisticsRequest 在 Search 匹配的作品范围内统计 tag 或作者，PageSize 小于等于 0 时每页 20 条
+type StatisticsRequest struct {
+	Search    SearchRequest `json:"search"`
+	Page      int           `json:"page"`
+	PageSize  int           `json:"size"`
+	MinCount  int           `json:"min_count"`
+	SortBy    string        `json:"sort_by"`
+	SortOrder string        `json:"sort_order"`
+}
@@ -0,0 +1,50 @@
+package structs
+
+type LibraryTotals struct {
+	Images           int `json:"images"`
+	Pages            int `json:"pages"`
+	Authors          int `json:"authors"`
+	FollowedAuthors  int `json:"followed_authors"`
+	Tags             int `json:"tags"`
+	LocalImages      int `json:"local_images"`
+	RemoteImages     int `json:"remote_images"`
+	BookmarkedImages int `json:"bookmarked_images"`
+}
+
+// BookmarkBucket 统计收藏数在 [Min, Max] 之间的作品，Max 为 -1 表示没有上限
+type BookmarkBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+type MonthCount struct {
+	Month string `json:"month"`
+	Count int    `json:"count"`
+}
+
+type IllustTypeCount struct {
+	IllustType int `json:"illust_type"`
+	Count      int `json:"count"`
+}
+
+type GalleryStorage struct {
+	ID    int    `json:"id"`
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"`
+}
+
+type LibraryStats struct {
+	Totals            LibraryTotals     `json:"totals"`
+	BookmarkHistogram []BookmarkBucket  `json:"bookmark_histogram"`
+	UploadMonths      []MonthCount      `json:"upload_months"`
+	IllustTypes       []IllustTypeCount `json:"illust_types"`
+	Storage           []GalleryStorage  `json:"storage"`
+	StorageBytes      int64             `json:"storage_bytes"`
+	// LastSuccess 为每种后台任务最近一次成功结束的时间
+	LastSuccess    map[string]int64 `json:"last_success"`
+	ScheduledTasks []ScheduledTask  `json:"scheduled_tasks"`
+	GeneratedAt    int64            `json:"generated_at"`
+}
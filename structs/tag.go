@@ -1,7 +1,35 @@
 package structs
 
 type Tag struct {
-	ID            int    `json:"id"`
-	Name          string `json:"name"`
-	TranslateName string `json:"translate_name"`
+	ID            int               `json:"id"`
+	Name          string            `json:"name"`
+	TranslateName string            `json:"translate_name"`
+	Translations  map[string]string `json:"translations,omitempty"`
+	Source        string            `json:"source,omitempty"`
+}
+
+// image_tag.source 的取值
+const (
+	TagSourcePixiv   = "pixiv"
+	TagSourceLocal   = "local"
+	TagSourceImplied = "implied"
+)
+
+type LocalTagPayload struct {
+	Tags []string `json:"tags"`
+}
+
+// BulkLocalTagPayload 对 Search 匹配的所有作品（不分页）加上 Add、去掉 Remove 里的本地 tag
+// BulkLocalTagPayload 的 search 不能为空，要对全部作品操作时需要用 all 明确指定
+type BulkLocalTagPayload struct {
+	Search SearchRequest `json:"search"`
+	All    bool          `json:"all"`
+	Add    []string      `json:"add"`
+	Remove []string      `json:"remove"`
+}
+
+type TagListRequest struct {
+	Page     int    `json:"page"`
+	PageSize int    `json:"size"`
+	Query    string `json:"q"`
 }
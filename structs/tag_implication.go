@@ -0,0 +1,23 @@
+package structs
+
+type TagImplication struct {
+	ID             int    `json:"id"`
+	TagID          int    `json:"tag_id"`
+	TagName        string `json:"tag_name"`
+	ImpliedTagID   int    `json:"implied_tag_id"`
+	ImpliedTagName string `json:"implied_tag_name"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// TagImplicationPayload 用 tag 名描述规则，Tag 蕴含 ImpliedTag
+type TagImplicationPayload struct {
+	Tag        string `json:"tag"`
+	ImpliedTag string `json:"implied_tag"`
+}
+
+// TagTreeNode 的 Children 是蕴含它的 tag，一个 tag 有多个上级时会在每个上级下各出现一次
+type TagTreeNode struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	Children []*TagTreeNode `json:"children,omitempty"`
+}
@@ -0,0 +1,20 @@
+package structs
+
+type TagRenamePayload struct {
+	Name string `json:"name"`
+}
+
+type TagMergePayload struct {
+	TargetID  int   `json:"target_id"`
+	SourceIDs []int `json:"source_ids"`
+}
+
+type TagAliasPayload struct {
+	Alias string `json:"alias"`
+}
+
+type TagAlias struct {
+	Alias     string `json:"alias"`
+	TagID     int    `json:"tag_id"`
+	CreatedAt int64  `json:"created_at"`
+}
@@ -0,0 +1,8 @@
+package structs
+
+type TagSuggestion struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	TranslateName string `json:"translate_name,omitempty"`
+	Count         int    `json:"count"`
+}
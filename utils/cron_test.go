@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"empty", ""},
+		{"too few fields", "* * * *"},
+		{"too many fields", "* * * * * *"},
+		{"unknown macro", "@fortnightly"},
+		{"minute out of range", "60 * * * *"},
+		{"hour out of range", "* 24 * * *"},
+		{"day of month zero", "* * 0 * *"},
+		{"month out of range", "* * * 13 *"},
+		{"day of week out of range", "* * * * 8"},
+		{"zero step", "*/0 * * * *"},
+		{"negative step", "*/-1 * * * *"},
+		{"reversed range", "5-1 * * * *"},
+		{"not a number", "a * * * *"},
+		{"bad range end", "1-b * * * *"},
+		{"empty list item", "1,,2 * * * *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.expr); err == nil {
+				t.Errorf("ParseCron(%q) succeeded, want error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", at(2024, 1, 1, 10, 7), at(2024, 1, 1, 10, 8)},
+		{"strictly after", "0 * * * *", at(2024, 1, 1, 10, 0), at(2024, 1, 1, 11, 0)},
+		{"seconds are dropped", "* * * * *", time.Date(2024, 1, 1, 10, 7, 59, 0, time.UTC), at(2024, 1, 1, 10, 8)},
+		{"macro", "@daily", at(2024, 1, 1, 10, 7), at(2024, 1, 2, 0, 0)},
+
+		{"step", "*/15 * * * *", at(2024, 1, 1, 10, 7), at(2024, 1, 1, 10, 15)},
+		{"step wraps to next hour", "*/15 * * * *", at(2024, 1, 1, 10, 45), at(2024, 1, 1, 11, 0)},
+		{"step from a start value", "5/20 * * * *", at(2024, 1, 1, 10, 30), at(2024, 1, 1, 10, 45)},
+		{"stepped range", "0 8-20/6 * * *", at(2024, 1, 1, 15, 0), at(2024, 1, 1, 20, 0)},
+
+		{"range inside", "0 9-17 * * *", at(2024, 1, 1, 12, 30), at(2024, 1, 1, 13, 0)},
+		{"range ends for the day", "0 9-17 * * *", at(2024, 1, 1, 17, 30), at(2024, 1, 2, 9, 0)},
+		{"list", "0 6,18 * * *", at(2024, 1, 1, 7, 0), at(2024, 1, 1, 18, 0)},
+		{"weekdays skip weekend", "0 9 * * 1-5", at(2024, 9, 6, 10, 0), at(2024, 9, 9, 9, 0)},
+		{"seven is sunday", "0 0 * * 7", at(2024, 9, 2, 0, 0), at(2024, 9, 8, 0, 0)},
+
+		// 日和周都被限制时满足其一即可
+		{"dom or dow hits dow first", "0 0 13 * 5", at(2024, 10, 10, 0, 0), at(2024, 10, 11, 0, 0)},
+		{"dom or dow hits dom first", "0 0 13 * 5", at(2024, 11, 12, 0, 0), at(2024, 11, 13, 0, 0)},
+		{"only dom restricted", "0 0 13 * *", at(2024, 10, 10, 0, 0), at(2024, 10, 13, 0, 0)},
+		{"only dow restricted", "0 0 * * 5", at(2024, 11, 12, 0, 0), at(2024, 11, 15, 0, 0)},
+
+		{"next month", "0 0 1 * *", at(2024, 1, 15, 0, 0), at(2024, 2, 1, 0, 0)},
+		{"next year", "30 23 * * *", at(2024, 12, 31, 23, 30), at(2025, 1, 1, 23, 30)},
+		{"skip months without the day", "0 0 31 * *", at(2024, 1, 31, 0, 0), at(2024, 3, 31, 0, 0)},
+		{"restricted month", "0 0 1 6 *", at(2024, 7, 1, 0, 0), at(2025, 6, 1, 0, 0)},
+		{"leap day", "0 0 29 2 *", at(2024, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		{"never", "0 0 30 2 *", at(2024, 1, 1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) failed: %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) for %q = %s, want %s", tt.from, tt.expr, got, tt.want)
+			}
+		})
+	}
+}